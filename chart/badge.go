@@ -0,0 +1,44 @@
+package chart
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Badge colors
+const (
+	BadgeGreen  = "#4c1"
+	BadgeYellow = "#dfb317"
+	BadgeRed    = "#e05d44"
+	BadgeBlue   = "#007ec6"
+	BadgeGray   = "#9f9f9f"
+)
+
+// Badge draws shields style badge with label on gray and value on color
+func Badge(label string, value string, color string) string {
+	lw := textWidth(label) + 10
+	vw := textWidth(value) + 10
+	w := lw + vw
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+
+		`<title>%s: %s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`+
+		`<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`+
+		`</g></svg>`,
+		w, esc(label), esc(value),
+		esc(label), esc(value),
+		w,
+		lw, lw, vw, color, w,
+		float64(lw)/2, esc(label), float64(lw)/2, esc(label),
+		float64(lw)+float64(vw)/2, esc(value), float64(lw)+float64(vw)/2, esc(value),
+	)
+}
+
+// textWidth estimates width of text in 11px Verdana
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)*7 + 1
+}
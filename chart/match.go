@@ -0,0 +1,156 @@
+package chart
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// Limits keep charts readable
+const (
+	maxPlayers = 15
+	maxLines   = 5
+	maxWeeks   = 52
+)
+
+// WinLoss draws wins, ties and losses of players, best first. Matches are
+// in any order.
+func WinLoss(matches []model.Match) string {
+	stats, _ := model.StatsFromMatches(matches)
+	sort.Sort(stats)
+	if len(stats) > maxPlayers {
+		stats = stats[:maxPlayers]
+	}
+
+	labels := make([]string, len(stats))
+	series := []Series{
+		{"Wins", make([]float64, len(stats))},
+		{"Ties", make([]float64, len(stats))},
+		{"Losses", make([]float64, len(stats))},
+	}
+	for i, st := range stats {
+		labels[i] = st.Name
+		series[0].Values[i] = float64(st.Wins)
+		series[1].Values[i] = float64(st.Ties)
+		series[2].Values[i] = float64(st.Losses)
+	}
+	return StackedBars("Wins and losses", labels, series)
+}
+
+// CumulativeWins draws wins over time of players with most wins
+func CumulativeWins(matches []model.Match) string {
+	ordered := byTime(matches)
+
+	wins := make(map[string]float64)
+	lines := make(map[string]*Line)
+	for _, m := range ordered {
+		if m.IsTie {
+			continue
+		}
+		added, err := time.Parse(time.RFC3339, m.Added)
+		if err != nil {
+			continue
+		}
+
+		line, ok := lines[m.Winner]
+		if !ok {
+			// Line starts from zero at first win
+			line = &Line{Name: m.Winner, Points: []Point{{added, 0}}}
+			lines[m.Winner] = line
+		}
+		wins[m.Winner]++
+		line.Points = append(line.Points, Point{added, wins[m.Winner]})
+	}
+
+	names := make([]string, 0, len(lines))
+	for name := range lines {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if wins[names[i]] != wins[names[j]] {
+			return wins[names[i]] > wins[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > maxLines {
+		names = names[:maxLines]
+	}
+
+	result := make([]Line, len(names))
+	for i, name := range names {
+		result[i] = *lines[name]
+	}
+	return Lines("Wins over time", result)
+}
+
+// GamesPerWeek draws number of matches in every week from first match to
+// last, weeks start on Monday
+func GamesPerWeek(matches []model.Match) string {
+	counts := make(map[time.Time]float64)
+	var first, last time.Time
+	for _, m := range matches {
+		added, err := time.Parse(time.RFC3339, m.Added)
+		if err != nil {
+			continue
+		}
+		week := weekStart(added)
+		counts[week]++
+		if first.IsZero() || week.Before(first) {
+			first = week
+		}
+		if week.After(last) {
+			last = week
+		}
+	}
+
+	labels := make([]string, 0)
+	values := make([]float64, 0)
+	if !first.IsZero() {
+		if last.Sub(first) > maxWeeks*7*24*time.Hour {
+			first = last.AddDate(0, 0, -7*(maxWeeks-1))
+		}
+		for week := first; !week.After(last); week = week.AddDate(0, 0, 7) {
+			labels = append(labels, week.Format("Jan 2"))
+			values = append(values, counts[week])
+		}
+	}
+	return StackedBars("Games per week", labels, []Series{{"Games", values}})
+}
+
+// HeadToHead draws wins of both players and ties between them, other
+// matches are ignored
+func HeadToHead(matches []model.Match, player1 string, player2 string) string {
+	values := make([]float64, 3)
+	for _, m := range matches {
+		switch {
+		case !involves(m, player1) || !involves(m, player2):
+		case m.IsTie:
+			values[2]++
+		case strings.EqualFold(m.Winner, player1):
+			values[0]++
+		default:
+			values[1]++
+		}
+	}
+	return Pie(player1+" vs "+player2, []string{player1, player2, "Ties"}, values)
+}
+
+// byTime returns copy of matches oldest first
+func byTime(matches []model.Match) []model.Match {
+	ordered := append([]model.Match(nil), matches...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Added < ordered[j].Added })
+	return ordered
+}
+
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-day, 0, 0, 0, 0, time.UTC)
+}
+
+func involves(m model.Match, player string) bool {
+	// Search matches names case insensitively too
+	return strings.EqualFold(m.Winner, player) || strings.EqualFold(m.Loser, player)
+}
@@ -0,0 +1,268 @@
+// Package chart draws small SVG charts on server, pages need no JavaScript
+// to show them
+package chart
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+)
+
+// Size of every chart, SVG scales to its container
+const (
+	width  = 640
+	height = 320
+	// Room for title, legend and axis labels
+	top    = 48
+	bottom = 40
+	left   = 40
+	right  = 16
+)
+
+// Palette series are drawn with, in order
+var Palette = []string{"#3273dc", "#ffdd57", "#ff3860", "#23d160", "#b86bff", "#ff9f43", "#00d1b2", "#a0a0a0"}
+
+// Series is one set of bar values, or one line
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// Point on time axis
+type Point struct {
+	X time.Time
+	Y float64
+}
+
+// Line is series of points in time order
+type Line struct {
+	Name   string
+	Points []Point
+}
+
+type svg struct {
+	b strings.Builder
+}
+
+func newSVG(title string) *svg {
+	s := &svg{}
+	fmt.Fprintf(&s.b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" class="chart" role="img" aria-label="%s">`,
+		width, height, esc(title))
+	s.text(width/2, 20, "middle", 16, title)
+	return s
+}
+
+func (s *svg) String() string {
+	return s.b.String() + "</svg>"
+}
+
+func (s *svg) text(x, y float64, anchor string, size int, text string) {
+	fmt.Fprintf(&s.b, `<text x="%.1f" y="%.1f" text-anchor="%s" font-size="%d" fill="currentColor">%s</text>`,
+		x, y, anchor, size, esc(text))
+}
+
+func (s *svg) rect(x, y, w, h float64, color string, tip string) {
+	fmt.Fprintf(&s.b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s</title></rect>`,
+		x, y, w, h, color, esc(tip))
+}
+
+func (s *svg) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&s.b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="currentColor" stroke-opacity="0.4"/>`,
+		x1, y1, x2, y2)
+}
+
+// legend draws names with their colors under title
+func (s *svg) legend(names []string) {
+	x := float64(left)
+	for i, name := range names {
+		s.rect(x, 30, 10, 10, color(i), name)
+		s.text(x+14, 39, "start", 11, name)
+		x += 14 + float64(len(name))*7 + 16
+	}
+}
+
+// axes draws y axis from zero to max and x axis
+func (s *svg) axes(max float64) {
+	s.line(left, top, left, height-bottom)
+	s.line(left, height-bottom, width-right, height-bottom)
+	s.text(left-4, height-bottom+4, "end", 10, "0")
+	s.text(left-4, top+4, "end", 10, formatValue(max))
+}
+
+// StackedBars draws bar for every label with series stacked on each other.
+// With one series it's plain bar chart.
+func StackedBars(title string, labels []string, series []Series) string {
+	s := newSVG(title)
+	if len(series) > 1 {
+		names := make([]string, len(series))
+		for i, se := range series {
+			names[i] = se.Name
+		}
+		s.legend(names)
+	}
+
+	max := 0.0
+	for i := range labels {
+		total := 0.0
+		for _, se := range series {
+			total += se.Values[i]
+		}
+		max = math.Max(max, total)
+	}
+	max = niceMax(max)
+	s.axes(max)
+
+	plotW := float64(width - left - right)
+	plotH := float64(height - top - bottom)
+	slot := plotW / math.Max(1, float64(len(labels)))
+	barW := slot * 0.7
+	// Label every nth bar so they don't overlap
+	every := int(math.Ceil(float64(len(labels)) * 50 / plotW))
+
+	for i, label := range labels {
+		x := left + float64(i)*slot + (slot-barW)/2
+		y := float64(height - bottom)
+		for j, se := range series {
+			h := se.Values[i] / max * plotH
+			y -= h
+			if h > 0 {
+				s.rect(x, y, barW, h, color(j), fmt.Sprintf("%s %s: %s", label, se.Name, formatValue(se.Values[i])))
+			}
+		}
+		if every <= 1 || i%every == 0 {
+			s.text(x+barW/2, height-bottom+14, "middle", 10, truncate(label, int(slot*float64(every)/6)))
+		}
+	}
+	return s.String()
+}
+
+// Lines draws lines on shared time axis
+func Lines(title string, lines []Line) string {
+	s := newSVG(title)
+	names := make([]string, len(lines))
+	for i, l := range lines {
+		names[i] = l.Name
+	}
+	s.legend(names)
+
+	var first, last time.Time
+	max := 0.0
+	for _, l := range lines {
+		for _, p := range l.Points {
+			if first.IsZero() || p.X.Before(first) {
+				first = p.X
+			}
+			if p.X.After(last) {
+				last = p.X
+			}
+			max = math.Max(max, p.Y)
+		}
+	}
+	max = niceMax(max)
+	s.axes(max)
+
+	if !first.IsZero() {
+		s.text(left, height-bottom+14, "start", 10, first.Format("2006-01-02"))
+		s.text(width-right, height-bottom+14, "end", 10, last.Format("2006-01-02"))
+	}
+
+	plotW := float64(width - left - right)
+	plotH := float64(height - top - bottom)
+	span := last.Sub(first).Seconds()
+
+	for i, l := range lines {
+		points := make([]string, 0, len(l.Points))
+		for _, p := range l.Points {
+			x := float64(left)
+			if span > 0 {
+				x += p.X.Sub(first).Seconds() / span * plotW
+			}
+			y := float64(height-bottom) - p.Y/max*plotH
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		fmt.Fprintf(&s.b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"><title>%s</title></polyline>`,
+			strings.Join(points, " "), color(i), esc(l.Name))
+	}
+	return s.String()
+}
+
+// Pie draws slices in proportion to values
+func Pie(title string, labels []string, values []float64) string {
+	s := newSVG(title)
+	s.legend(labels)
+
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+
+	cx := float64(width / 2)
+	cy := float64(top+height-8) / 2
+	r := float64(height-top-16) / 2
+
+	angle := -math.Pi / 2
+	for i, v := range values {
+		if v <= 0 {
+			continue
+		}
+		tip := fmt.Sprintf("%s: %s (%.0f%%)", labels[i], formatValue(v), v/total*100)
+		if v == total {
+			fmt.Fprintf(&s.b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"><title>%s</title></circle>`,
+				cx, cy, r, color(i), esc(tip))
+			break
+		}
+
+		end := angle + v/total*2*math.Pi
+		large := 0
+		if end-angle > math.Pi {
+			large = 1
+		}
+		fmt.Fprintf(&s.b, `<path d="M%.1f,%.1f L%.1f,%.1f A%.1f,%.1f 0 %d 1 %.1f,%.1f Z" fill="%s"><title>%s</title></path>`,
+			cx, cy, cx+r*math.Cos(angle), cy+r*math.Sin(angle), r, r, large, cx+r*math.Cos(end), cy+r*math.Sin(end),
+			color(i), esc(tip))
+		angle = end
+	}
+	return s.String()
+}
+
+func color(i int) string {
+	return Palette[i%len(Palette)]
+}
+
+// niceMax rounds max up to 1, 2 or 5 times power of ten
+func niceMax(max float64) float64 {
+	if max <= 0 {
+		return 1
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(max)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*pow >= max {
+			return m * pow
+		}
+	}
+	return max
+}
+
+func formatValue(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n < 1 {
+		n = 1
+	}
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
@@ -0,0 +1,197 @@
+// Package cli runs jumbo's subcommands against the database, so it can be
+// maintained without web UI
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/logging"
+	"github.com/tuommii/jumbo/server"
+)
+
+// Database file when -db or JUMBO_DB is not given
+const defaultDB = "jumbo.db"
+
+// ErrUsage is returned when command is unknown or has wrong arguments
+var ErrUsage = errors.New("bad usage, see jumbo help")
+
+// Output of commands
+var out io.Writer = os.Stdout
+
+// Input of commands that read it
+var in io.Reader = os.Stdin
+
+// command runs with opened database and rest of arguments
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *app, args []string) error
+}
+
+// app is what commands need
+type app struct {
+	db *database.SQLiteDB
+	// store records writes to audit log
+	store database.Database
+}
+
+var commands = map[string]command{
+	"serve":           {"serve", serve},
+	"player add":      {"player add <name>", playerAdd},
+	"player rm":       {"player rm <name>", playerRm},
+	"player rename":   {"player rename <name> <new name>", playerRename},
+	"player list":     {"player list", playerList},
+	"player password": {"player password <name>", playerPassword},
+	"game add":        {"game add <name>", gameAdd},
+	"game rm":         {"game rm <name>", gameRm},
+	"game list":       {"game list", gameList},
+	"match add":       {"match add -game <game> -winner <player> -loser <player> [-tie] [-comment <text>] [-winner-score <n> -loser-score <n>] [-reporter <player>]", matchAdd},
+	"match list":      {"match list [filter]", matchList},
+	"match rm":        {"match rm <id>", matchRm},
+	"stats":           {"stats [filter]", stats},
+	"import":          {"import [-format csv|pgn] [-game <game>] [-create] [-commit] <file>", importFile},
+	"export":          {"export [-format csv|json|ndjson|pgn] [-stats] [filter]", exportData},
+	"migrate":         {"migrate", migrate},
+	"webhook add":     {"webhook add [-events <event,...>] <url> <secret>", webhookAdd},
+	"webhook rm":      {"webhook rm <id>", webhookRm},
+	"webhook list":    {"webhook list", webhookList},
+}
+
+// Run runs command in args, program name excluded. No command runs server.
+func Run(args []string) error {
+	fs := flag.NewFlagSet("jumbo", flag.ContinueOnError)
+	dbFile := fs.String("db", getenv("JUMBO_DB", defaultDB), "database file")
+	fs.Usage = usage
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		usage()
+		return nil
+	case "restore":
+		// Database must not be opened, it's replaced
+		if len(args) != 2 {
+			return ErrUsage
+		}
+		err := database.RestoreBackup(args[1], *dbFile)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Restored", args[1])
+		return nil
+	}
+
+	name := args[0]
+	rest := args[1:]
+	if _, ok := commands[name]; !ok && len(args) > 1 {
+		name = args[0] + " " + args[1]
+		rest = args[2:]
+	}
+
+	// Receiver is for testing webhooks, it doesn't need database
+	if name == "webhook receive" {
+		return webhookReceive(rest)
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return ErrUsage
+	}
+
+	logging.Setup()
+
+	db, err := database.NewSQLiteDB(*dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Connection.Close()
+
+	slow, err := time.ParseDuration(os.Getenv("SLOW_QUERY"))
+	if err == nil {
+		db.SlowQuery = slow
+	}
+
+	// Interrupt cancels running queries, and stops server gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := &app{db: db, store: database.WithAudit(db, actor())}
+	return cmd.run(ctx, c, rest)
+}
+
+func serve(ctx context.Context, c *app, args []string) error {
+	s, err := server.Create(c.db)
+	if err != nil {
+		return err
+	}
+	return s.Start(ctx)
+}
+
+func migrate(ctx context.Context, c *app, args []string) error {
+	// Opening database ran migrations
+	version, err := c.db.Version(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Schema version %d\n", version)
+	return nil
+}
+
+// actor is user running command, for audit log
+func actor() database.Actor {
+	name := getenv("USER", "unknown")
+	return database.Actor{Name: "cli:" + name, RemoteAddr: "local"}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(out, "Usage: jumbo [-db file] <command>")
+	fmt.Fprintln(out)
+	for _, name := range names {
+		fmt.Fprintln(out, "  jumbo", commands[name].usage)
+	}
+	fmt.Fprintln(out, "  jumbo restore <backup>")
+	fmt.Fprintln(out, "  jumbo webhook receive [-addr <host:port>] [-secret <secret>] [-status <code>]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "filter: [-game <game>] [-player <player>] [-vs <player>] [-days <n>] [-limit <n>]")
+}
+
+// table writes rows as aligned columns
+func table(header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	return tw
+}
+
+// getenv returns environment variable or fallback if it's not set
+func getenv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
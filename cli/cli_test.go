@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+func TestFilterFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want model.Filter
+		rest []string
+		err  bool
+	}{
+		{"none", nil, model.Filter{}, []string{}, false},
+		{
+			"all",
+			[]string{"-game", "chess", "-player", "alice", "-vs", "bob", "-days", "7", "-limit", "3"},
+			model.Filter{GameName: "chess", Player1: "alice", Player2: "bob", LimitDays: 7, LimitGames: 3},
+			[]string{},
+			false,
+		},
+		{"vs without player", []string{"-vs", "bob"}, model.Filter{Player1: "bob"}, []string{}, false},
+		{"arguments after flags", []string{"-game", "go", "extra"}, model.Filter{GameName: "go"}, []string{"extra"}, false},
+		{"bad number", []string{"-days", "week"}, model.Filter{}, nil, true},
+		{"unknown flag", []string{"-winner", "alice"}, model.Filter{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flags("test")
+			fs.SetOutput(&bytes.Buffer{})
+			f := filterFlags(fs)
+
+			err := fs.Parse(tt.args)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, want error %v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if got := filter(f); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got filter %+v, want %+v", got, tt.want)
+			}
+			if strings.Join(fs.Args(), " ") != strings.Join(tt.rest, " ") {
+				t.Errorf("got arguments %q, want %q", fs.Args(), tt.rest)
+			}
+		})
+	}
+}
+
+// run runs commands against fresh database and returns output of last
+func run(t *testing.T, commands ...[]string) (string, error) {
+	db := filepath.Join(t.TempDir(), "test.db")
+	var buf bytes.Buffer
+	stdout := out
+	out = &buf
+	t.Cleanup(func() { out = stdout })
+
+	var err error
+	for _, args := range commands {
+		buf.Reset()
+		err = Run(append([]string{"-db", db}, args...))
+		if err != nil {
+			break
+		}
+	}
+	return buf.String(), err
+}
+
+func TestRunErrors(t *testing.T) {
+	setup := [][]string{
+		{"player", "add", "alice"},
+		{"player", "add", "bob"},
+		{"game", "add", "chess"},
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		// err is ErrUsage when empty
+		err string
+	}{
+		{"unknown command", []string{"frobnicate"}, ""},
+		{"unknown subcommand", []string{"player", "kick", "alice"}, ""},
+		{"missing argument", []string{"player", "add"}, ""},
+		{"extra argument", []string{"game", "rm", "chess", "go"}, ""},
+		{"restore without file", []string{"restore"}, ""},
+		{"match without loser", []string{"match", "add", "-game", "chess", "-winner", "alice"}, ""},
+		{"match rm without id", []string{"match", "rm"}, ""},
+		{"same player", []string{"match", "add", "-game", "chess", "-winner", "alice", "-loser", "Alice"}, "same player"},
+		{"bad flag", []string{"match", "list", "-days", "week"}, "invalid value"},
+		{"bad id", []string{"match", "rm", "first"}, "invalid syntax"},
+		{"no such match", []string{"match", "rm", "42"}, "no match 42"},
+		{"no such player", []string{"player", "rename", "carol", "dave"}, "no player carol"},
+		{"no such game", []string{"game", "rm", "go"}, "no game go"},
+		{"player in trash", []string{"player", "add", "bob"}, "in trash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := append([][]string{}, setup...)
+			if tt.name == "player in trash" {
+				commands = append(commands, []string{"player", "rm", "bob"})
+			}
+			commands = append(commands, tt.args)
+
+			_, err := run(t, commands...)
+			if tt.err == "" {
+				if !errors.Is(err, ErrUsage) {
+					t.Errorf("got error %v, want %v", err, ErrUsage)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	setup := [][]string{
+		{"player", "add", "alice"},
+		{"player", "add", "bob"},
+		{"player", "add", "carol"},
+		{"game", "add", "chess"},
+		{"game", "add", "go"},
+		{"match", "add", "-game", "chess", "-winner", "alice", "-loser", "bob", "-winner-score", "3", "-loser-score", "1"},
+		{"match", "add", "-game", "go", "-winner", "carol", "-loser", "alice", "-comment", "close one"},
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		lines []string
+	}{
+		{"add player", []string{"player", "add", "dave"}, []string{"Added player dave"}},
+		{"players sorted", []string{"player", "list"}, []string{"ID", "alice", "bob", "carol"}},
+		// Matches added in same second have no order
+		{"all matches", []string{"match", "list"}, []string{"ID", "alice", "alice"}},
+		{"matches of game", []string{"match", "list", "-game", "go"}, []string{"ID", "close one"}},
+		{"matches vs player", []string{"match", "list", "-vs", "bob"}, []string{"ID", "3-1"}},
+		{"stats of player", []string{"stats", "-player", "alice"}, []string{"NAME", "alice"}},
+		{"remove match", []string{"match", "rm", "1"}, []string{"Moved match to trash 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, append(append([][]string{}, setup...), tt.args)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(got), "\n")
+			if len(lines) != len(tt.lines) {
+				t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(tt.lines), got)
+			}
+			for i, want := range tt.lines {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("line %d is %q, want it to contain %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
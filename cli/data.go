@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/tuommii/jumbo/export"
+	"github.com/tuommii/jumbo/importer"
+	"github.com/tuommii/jumbo/model"
+)
+
+func stats(ctx context.Context, c *app, args []string) error {
+	fs := flags("stats")
+	f := filterFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	sorted, err := collectStats(ctx, c, filter(f))
+	if err != nil {
+		return err
+	}
+
+	tw := table("NAME", "GAMES", "WINS", "TIES", "LOSSES", "WIN%", "STREAK", "BEST")
+	for _, s := range sorted {
+		// Matches include opponents, show only asked player
+		if f.Player1 != "" && f.Player2 == "" && s.Name != f.Player1 {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f\t%d\t%d\n",
+			s.Name, s.Games, s.Wins, s.Ties, s.Losses, s.WinPercentage*100, s.CurrentWinStreak, s.HighestWinStreak)
+	}
+	return tw.Flush()
+}
+
+// collectStats computes stats from matches while reading them
+func collectStats(ctx context.Context, c *app, f model.Filter) (model.SortedStats, error) {
+	players := make(model.StatsMap)
+	err := c.db.EachMatch(ctx, f, func(match model.Match) error {
+		players.Init(match.Winner, match.Loser)
+		players.Increase(match.Winner, match.Loser, match.IsTie)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	players.CalculateComputed()
+	sorted := players.Sorted()
+	sort.Sort(sorted)
+	return sorted, nil
+}
+
+func exportData(ctx context.Context, c *app, args []string) error {
+	fs := flags("export")
+	format := fs.String("format", export.CSV, "csv, json, ndjson or pgn")
+	statsOnly := fs.Bool("stats", false, "export stats instead of matches")
+	f := filterFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *statsOnly {
+		sorted, err := collectStats(ctx, c, filter(f))
+		if err != nil {
+			return err
+		}
+		return export.WriteStats(out, *format, sorted)
+	}
+
+	mw, err := export.NewMatchWriter(out, *format)
+	if err != nil {
+		return err
+	}
+
+	err = c.db.EachMatch(ctx, filter(f), mw.Write)
+	if err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+// importFile prints dry run report, matches are saved only with -commit
+func importFile(ctx context.Context, c *app, args []string) error {
+	fs := flags("import")
+	format := fs.String("format", "csv", "csv or pgn")
+	gameName := fs.String("game", "", "game of PGN matches")
+	createMissing := fs.Bool("create", false, "create missing players and games")
+	commit := fs.Bool("commit", false, "save matches")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return ErrUsage
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var rows []importer.Row
+
+	switch *format {
+	case "csv":
+		rows, err = importer.ReadCSV(file)
+	case "pgn":
+		if *gameName == "" {
+			return fmt.Errorf("-game required for PGN")
+		}
+		var aliases []model.Alias
+		aliases, err = c.db.GetAliases(ctx)
+		if err != nil {
+			return err
+		}
+		rows, err = importer.ReadPGN(file, *gameName, aliases)
+	default:
+		return ErrUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	players, err := c.db.GetPlayers(ctx)
+	if err != nil {
+		return err
+	}
+	games, err := c.db.GetGames(ctx)
+	if err != nil {
+		return err
+	}
+	trashed, err := c.db.GetDeleted(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := c.db.GetMatches(ctx, model.Filter{})
+	if err != nil {
+		return err
+	}
+
+	rep := importer.Check(rows, players, games, trashed, existing, *createMissing)
+
+	fmt.Fprintf(out, "%d rows, %d with errors, %d duplicates skipped\n", len(rep.Rows), rep.Invalid, rep.Duplicates)
+	if len(rep.MissingPlayers) > 0 {
+		fmt.Fprintln(out, "New players:", rep.MissingPlayers)
+	}
+	if len(rep.MissingGames) > 0 {
+		fmt.Fprintln(out, "New games:", rep.MissingGames)
+	}
+	for _, row := range rep.Rows {
+		if len(row.Errors) > 0 {
+			fmt.Fprintf(out, "line %d: %v\n", row.Line, row.Errors)
+		}
+	}
+
+	if !*commit {
+		return nil
+	}
+	if !rep.CanImport() {
+		return fmt.Errorf("fix errors before importing")
+	}
+
+	num, err := c.store.ImportMatches(ctx, rep.Import())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Imported", num, "matches")
+	return nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+func gameAdd(ctx context.Context, c *app, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	_, err := c.store.CreateGame(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Added game", args[0])
+	return nil
+}
+
+func gameRm(ctx context.Context, c *app, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	num, err := c.store.DeleteGame(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("no game %s", args[0])
+	}
+	fmt.Fprintln(out, "Moved game to trash", args[0])
+	return nil
+}
+
+func gameList(ctx context.Context, c *app, args []string) error {
+	games, err := c.db.GetGames(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].Name < games[j].Name })
+
+	tw := table("ID", "NAME", "CONFIRM")
+	for _, g := range games {
+		fmt.Fprintf(tw, "%d\t%s\t%t\n", g.ID, g.Name, g.Confirm)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// flags returns flag set for subcommand, errors are returned not printed
+func flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(out)
+	return fs
+}
+
+// filterFlags adds same filters as search has
+func filterFlags(fs *flag.FlagSet) *model.Filter {
+	f := &model.Filter{}
+	fs.StringVar(&f.GameName, "game", "", "game name")
+	fs.StringVar(&f.Player1, "player", "", "player name")
+	fs.StringVar(&f.Player2, "vs", "", "opponent's name")
+	fs.IntVar(&f.LimitDays, "days", 0, "only last days")
+	fs.IntVar(&f.LimitGames, "limit", 0, "max matches")
+	return f
+}
+
+func matchAdd(ctx context.Context, c *app, args []string) error {
+	fs := flags("match add")
+	m := model.Match{}
+	fs.StringVar(&m.GameName, "game", "", "game name")
+	fs.StringVar(&m.Winner, "winner", "", "winner's name")
+	fs.StringVar(&m.Loser, "loser", "", "loser's name")
+	fs.BoolVar(&m.IsTie, "tie", false, "match was tie")
+	fs.StringVar(&m.Comment, "comment", "", "comment")
+	fs.StringVar(&m.ReportedBy, "reporter", "", "player who reported, needed when game has confirmation")
+	winnerScore := fs.Int("winner-score", -1, "winner's score")
+	loserScore := fs.Int("loser-score", -1, "loser's score")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if m.GameName == "" || m.Winner == "" || m.Loser == "" {
+		return ErrUsage
+	}
+	if strings.EqualFold(m.Winner, m.Loser) {
+		return fmt.Errorf("winner and loser cant be same player")
+	}
+
+	// SQL might cry for empty strings
+	if m.Comment == "" {
+		m.Comment = "EMPTY"
+	}
+	if *winnerScore >= 0 && *loserScore >= 0 {
+		m.WinnerScore = winnerScore
+		m.LoserScore = loserScore
+	}
+
+	id, err := c.store.CreateMatch(ctx, m)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Added match", id)
+	return nil
+}
+
+func matchList(ctx context.Context, c *app, args []string) error {
+	fs := flags("match list")
+	f := filterFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	tw := table("ID", "ADDED", "GAME", "WINNER", "LOSER", "TIE", "SCORE", "COMMENT")
+	err = c.db.EachMatch(ctx, filter(f), func(m model.Match) error {
+		score := ""
+		if m.WinnerScore != nil && m.LoserScore != nil {
+			score = fmt.Sprintf("%d-%d", *m.WinnerScore, *m.LoserScore)
+		}
+		_, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%t\t%s\t%s\n",
+			m.ID, m.Added, m.GameName, m.Winner, m.Loser, m.IsTie, score, m.Comment)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+func matchRm(ctx context.Context, c *app, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	num, err := c.store.DeleteMatch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("no match %d", id)
+	}
+	fmt.Fprintln(out, "Moved match to trash", id)
+	return nil
+}
+
+// filter fixes -vs given without -player
+func filter(f *model.Filter) model.Filter {
+	if f.Player1 == "" {
+		f.Player1, f.Player2 = f.Player2, ""
+	}
+	return *f
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+func playerAdd(ctx context.Context, c *app, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	_, err := c.store.CreatePlayer(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Added player", args[0])
+	return nil
+}
+
+func playerRm(ctx context.Context, c *app, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	num, err := c.store.DeletePlayer(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("no player %s", args[0])
+	}
+	fmt.Fprintln(out, "Moved player to trash", args[0])
+	return nil
+}
+
+func playerRename(ctx context.Context, c *app, args []string) error {
+	if len(args) != 2 {
+		return ErrUsage
+	}
+
+	num, err := c.store.RenamePlayer(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("no player %s", args[0])
+	}
+	fmt.Fprintln(out, "Renamed", args[0], "to", args[1])
+	return nil
+}
+
+func playerList(ctx context.Context, c *app, args []string) error {
+	players, err := c.db.GetPlayers(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+
+	tw := table("ID", "NAME")
+	for _, p := range players {
+		fmt.Fprintf(tw, "%d\t%s\n", p.ID, p.Name)
+	}
+	return tw.Flush()
+}
+
+// playerPassword reads password from first line of input, so it doesn't end
+// up in shell history. Empty line removes password.
+func playerPassword(ctx context.Context, c *app, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	password := strings.TrimRight(line, "\r\n")
+
+	num, err := c.store.SetPlayerPassword(ctx, args[0], password)
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("no player %s", args[0])
+	}
+	if password == "" {
+		fmt.Fprintln(out, "Removed password of", args[0])
+		return nil
+	}
+	fmt.Fprintln(out, "Set password of", args[0])
+	return nil
+}
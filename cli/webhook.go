@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/tuommii/jumbo/model"
+	"github.com/tuommii/jumbo/webhook"
+)
+
+func webhookAdd(ctx context.Context, c *app, args []string) error {
+	fs := flag.NewFlagSet("webhook add", flag.ContinueOnError)
+	events := fs.String("events", "", "comma separated events, all if empty: "+strings.Join(model.Events, ","))
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return ErrUsage
+	}
+
+	hook := model.Webhook{URL: fs.Arg(0), Secret: fs.Arg(1)}
+	if *events != "" {
+		hook.Events = strings.Split(*events, ",")
+	}
+
+	id, err := c.store.CreateWebhook(ctx, hook)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Added webhook", id)
+	return nil
+}
+
+func webhookRm(ctx context.Context, c *app, args []string) error {
+	if len(args) != 1 {
+		return ErrUsage
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return ErrUsage
+	}
+
+	num, err := c.store.DeleteWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return fmt.Errorf("no webhook %d", id)
+	}
+	fmt.Fprintln(out, "Deleted webhook", id)
+	return nil
+}
+
+func webhookList(ctx context.Context, c *app, args []string) error {
+	webhooks, err := c.db.GetWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := table("ID", "URL", "EVENTS")
+	for _, w := range webhooks {
+		events := w.EventList()
+		if events == "" {
+			events = "all"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", w.ID, w.URL, events)
+	}
+	return tw.Flush()
+}
+
+// webhookReceive prints deliveries it gets, for testing webhooks locally.
+// It doesn't use database.
+func webhookReceive(args []string) error {
+	fs := flag.NewFlagSet("webhook receive", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:9000", "address to listen")
+	secret := fs.String("secret", "", "check signatures with secret")
+	status := fs.Int("status", http.StatusOK, "status to answer with, to test retries")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		signature := "not checked"
+		if *secret != "" {
+			signature = "ok"
+			if !webhook.Verify(*secret, body, r.Header.Get(webhook.HeaderSignature)) {
+				signature = "BAD"
+			}
+		}
+
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") != nil {
+			pretty.Reset()
+			pretty.Write(body)
+		}
+
+		fmt.Fprintf(out, "%s delivery %s, signature %s\n%s\n\n",
+			r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery), signature, pretty.String())
+
+		if signature == "BAD" {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(*status)
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: *addr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	fmt.Fprintln(out, "Listening on", *addr)
+	err = srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+// Package client talks to Jumbo server's HTTP API
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// Client for one server, token is sent as bearer token
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// Error is non-success response from server
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("server: %d %s", e.StatusCode, e.Message)
+}
+
+// New returns client for server at baseURL
+func New(baseURL string, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Players returns all players ordered by name
+func (c *Client) Players() ([]model.Player, error) {
+	players := make([]model.Player, 0)
+	err := c.get("/api/players", nil, &players)
+	return players, err
+}
+
+// Games returns all games ordered by name
+func (c *Client) Games() ([]model.Game, error) {
+	games := make([]model.Game, 0)
+	err := c.get("/api/games", nil, &games)
+	return games, err
+}
+
+// Search returns confirmed matches matching filter, latest first
+func (c *Client) Search(f model.Filter) ([]model.Match, error) {
+	q := filterQuery(f)
+	q.Set("format", "json")
+
+	matches := make([]model.Match, 0)
+	err := c.get("/api/export/matches", q, &matches)
+	return matches, err
+}
+
+// Leaderboard returns players' stats for filter, best first
+func (c *Client) Leaderboard(f model.Filter) (model.SortedStats, error) {
+	q := filterQuery(f)
+	q.Set("format", "json")
+
+	stats := make(model.SortedStats, 0)
+	err := c.get("/api/export/stats", q, &stats)
+	return stats, err
+}
+
+// CreateMatch saves match and returns it as saved, it may be pending if
+// game needs confirmation
+func (c *Client) CreateMatch(m model.Match) (model.Match, error) {
+	form := url.Values{}
+	form.Set("gameName", m.GameName)
+	form.Set("winner", m.Winner)
+	form.Set("loser", m.Loser)
+	form.Set("comment", m.Comment)
+	if m.IsTie {
+		form.Set("isTie", "tie")
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+"/api/create/match", strings.NewReader(form.Encode()))
+	if err != nil {
+		return m, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	saved := model.Match{}
+	err = c.do(req, &saved)
+	return saved, err
+}
+
+func (c *Client) get(path string, q url.Values, v interface{}) error {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, v)
+}
+
+// do sends request and decodes JSON response to v
+func (c *Client) do(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return &Error{StatusCode: res.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// filterQuery uses same parameter names as search form
+func filterQuery(f model.Filter) url.Values {
+	q := url.Values{}
+	if f.GameName != "" {
+		q.Set("gameName", f.GameName)
+	}
+	if f.Player1 != "" {
+		q.Set("player1", f.Player1)
+	}
+	if f.Player2 != "" {
+		q.Set("player2", f.Player2)
+	}
+	if f.LimitDays > 0 {
+		q.Set("limitDays", strconv.Itoa(f.LimitDays))
+	}
+	if f.LimitGames > 0 {
+		q.Set("limitGames", strconv.Itoa(f.LimitGames))
+	}
+	return q
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// request is what server saw
+type request struct {
+	method string
+	path   string
+	query  string
+	auth   string
+	accept string
+	form   string
+}
+
+// serve returns client for server answering status and body, and request it
+// received
+func serve(t *testing.T, token string, status int, body string) (*Client, *request) {
+	got := &request{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		*got = request{
+			method: r.Method,
+			path:   r.URL.Path,
+			query:  r.URL.RawQuery,
+			auth:   r.Header.Get("Authorization"),
+			accept: r.Header.Get("Accept"),
+			form:   r.PostForm.Encode(),
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(ts.Close)
+
+	// Trailing slash is trimmed
+	return New(ts.URL+"/", token), got
+}
+
+func TestClientRequests(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		call  func(c *Client) (interface{}, error)
+		body  string
+		want  interface{}
+		req   request
+	}{
+		{
+			name:  "players with token",
+			token: "secret",
+			call:  func(c *Client) (interface{}, error) { return c.Players() },
+			body:  `[{"id":1,"name":"alice"},{"id":2,"name":"bob"}]`,
+			want:  []model.Player{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}},
+			req:   request{method: "GET", path: "/api/players", auth: "Bearer secret", accept: "application/json"},
+		},
+		{
+			name: "games without token",
+			call: func(c *Client) (interface{}, error) { return c.Games() },
+			body: `[]`,
+			want: []model.Game{},
+			req:  request{method: "GET", path: "/api/games", accept: "application/json"},
+		},
+		{
+			name: "search with filter",
+			call: func(c *Client) (interface{}, error) {
+				return c.Search(model.Filter{GameName: "chess", Player1: "alice", LimitGames: 5})
+			},
+			body: `[{"id":3,"name":"chess","winner":"alice","loser":"bob"}]`,
+			want: []model.Match{{ID: 3, GameName: "chess", Winner: "alice", Loser: "bob"}},
+			req:  request{method: "GET", path: "/api/export/matches", query: "format=json&gameName=chess&limitGames=5&player1=alice", accept: "application/json"},
+		},
+		{
+			name: "leaderboard",
+			call: func(c *Client) (interface{}, error) { return c.Leaderboard(model.Filter{LimitDays: 7}) },
+			body: `[{"name":"alice","wins":2}]`,
+			want: model.SortedStats{{Name: "alice", Wins: 2}},
+			req:  request{method: "GET", path: "/api/export/stats", query: "format=json&limitDays=7", accept: "application/json"},
+		},
+		{
+			name:  "create match",
+			token: "secret",
+			call: func(c *Client) (interface{}, error) {
+				return c.CreateMatch(model.Match{GameName: "chess", Winner: "alice", Loser: "bob", IsTie: true, Comment: "gg"})
+			},
+			body: `{"id":4,"name":"chess","winner":"alice","loser":"bob","isTie":true,"status":"pending"}`,
+			want: model.Match{ID: 4, GameName: "chess", Winner: "alice", Loser: "bob", IsTie: true, Status: "pending"},
+			req:  request{method: "POST", path: "/api/create/match", auth: "Bearer secret", accept: "application/json", form: "comment=gg&gameName=chess&isTie=tie&loser=bob&winner=alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, req := serve(t, tt.token, http.StatusOK, tt.body)
+
+			got, err := tt.call(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if *req != tt.req {
+				t.Errorf("got request %+v, want %+v", *req, tt.req)
+			}
+		})
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   *Error
+	}{
+		{"unauthorized", http.StatusUnauthorized, "Unauthorized\n", &Error{StatusCode: 401, Message: "Unauthorized"}},
+		{"server error", http.StatusInternalServerError, "  database is locked \n", &Error{StatusCode: 500, Message: "database is locked"}},
+		{"not modified is not success", http.StatusNotModified, "", &Error{StatusCode: 304}},
+		{"bad JSON", http.StatusOK, "<html>", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := serve(t, "", tt.status, tt.body)
+
+			_, err := c.Players()
+			if err == nil {
+				t.Fatal("got no error")
+			}
+
+			var got *Error
+			if !errors.As(err, &got) {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Completion scripts ask names from server with players and games commands
+const bashCompletion = `_jumboctl() {
+	local cur prev
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "login add search leaderboard players games completion help" -- "$cur"))
+		return
+	fi
+
+	local IFS=$'\n'
+	case "$prev" in
+	-game)
+		COMPREPLY=($(compgen -W "$(jumboctl games 2>/dev/null)" -- "$cur"))
+		;;
+	-winner|-loser|-player|-vs)
+		COMPREPLY=($(compgen -W "$(jumboctl players 2>/dev/null)" -- "$cur"))
+		;;
+	*)
+		COMPREPLY=($(compgen -W "-game -winner -loser -tie -comment -player -vs -days -limit -json" -- "$cur"))
+		;;
+	esac
+}
+complete -F _jumboctl jumboctl
+`
+
+const zshCompletion = `#compdef jumboctl
+autoload -U bashcompinit && bashcompinit
+` + bashCompletion
+
+func completion(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	default:
+		return errUsage
+	}
+	return nil
+}
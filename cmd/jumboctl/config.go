@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// config is saved by login, JUMBO_URL and JUMBO_TOKEN override it
+type config struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jumbo", "config.json"), nil
+}
+
+func loadConfig() (config, error) {
+	conf := config{}
+
+	path, err := configPath()
+	if err != nil {
+		return conf, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return conf, err
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &conf)
+		if err != nil {
+			return conf, err
+		}
+	}
+
+	if url := os.Getenv("JUMBO_URL"); url != "" {
+		conf.URL = url
+	}
+	if token := os.Getenv("JUMBO_TOKEN"); token != "" {
+		conf.Token = token
+	}
+	return conf, nil
+}
+
+// save writes config readable only by user, token is a secret
+func (c config) save() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0600)
+}
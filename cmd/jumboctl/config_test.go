@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		saved config
+		url   string
+		token string
+		want  config
+	}{
+		{"nothing saved", config{}, "", "", config{}},
+		{"saved", config{URL: "https://jumbo.example", Token: "secret"}, "", "", config{URL: "https://jumbo.example", Token: "secret"}},
+		{"environment overrides", config{URL: "https://jumbo.example", Token: "secret"}, "http://localhost:8080", "other", config{URL: "http://localhost:8080", Token: "other"}},
+		{"environment without file", config{}, "http://localhost:8080", "", config{URL: "http://localhost:8080"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", dir)
+			t.Setenv("JUMBO_URL", tt.url)
+			t.Setenv("JUMBO_TOKEN", tt.token)
+
+			if tt.saved != (config{}) {
+				path, err := tt.saved.save()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if path != filepath.Join(dir, "jumbo", "config.json") {
+					t.Errorf("saved to %s", path)
+				}
+
+				// Token is a secret
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != 0600 {
+					t.Errorf("got mode %v, want 0600", info.Mode().Perm())
+				}
+			}
+
+			got, err := loadConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigBroken(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	err := os.MkdirAll(filepath.Join(dir, "jumbo"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "jumbo", "config.json"), []byte("{url:"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = loadConfig()
+	if err == nil {
+		t.Error("got no error for broken config")
+	}
+}
@@ -0,0 +1,248 @@
+// Command jumboctl logs matches and reads stats from Jumbo server
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tuommii/jumbo/client"
+	"github.com/tuommii/jumbo/model"
+)
+
+var errUsage = errors.New("bad usage, see jumboctl help")
+
+type command struct {
+	usage string
+	run   func(c *client.Client, args []string) error
+}
+
+var commands = map[string]command{
+	"add":         {"add -game <game> -winner <player> -loser <player> [-tie] [-comment <text>]", add},
+	"search":      {"search [filter] [-json]", search},
+	"leaderboard": {"leaderboard [filter] [-json]", leaderboard},
+	"players":     {"players [-json]", players},
+	"games":       {"games [-json]", games},
+}
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		usage()
+		return errUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		usage()
+		return nil
+	case "login":
+		return login(args[1:])
+	case "completion":
+		return completion(args[1:])
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return errUsage
+	}
+
+	conf, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if conf.URL == "" {
+		return errors.New("no server, run jumboctl login <url> <token>")
+	}
+
+	return cmd.run(client.New(conf.URL, conf.Token), args[1:])
+}
+
+func login(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	conf := config{URL: args[0], Token: args[1]}
+
+	// Check before saving
+	_, err := client.New(conf.URL, conf.Token).Games()
+	if err != nil {
+		return err
+	}
+
+	path, err := conf.save()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Saved", path)
+	return nil
+}
+
+func add(c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	m := model.Match{}
+	fs.StringVar(&m.GameName, "game", "", "game name")
+	fs.StringVar(&m.Winner, "winner", "", "winner's name")
+	fs.StringVar(&m.Loser, "loser", "", "loser's name")
+	fs.BoolVar(&m.IsTie, "tie", false, "match was tie")
+	fs.StringVar(&m.Comment, "comment", "", "comment")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if m.GameName == "" || m.Winner == "" || m.Loser == "" {
+		return errUsage
+	}
+
+	saved, err := c.CreateMatch(m)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added match %d: %s | %s - %s", saved.ID, saved.GameName, saved.Winner, saved.Loser)
+	if saved.Status == model.MatchPending {
+		fmt.Print(", waiting for opponent's confirmation")
+	}
+	fmt.Println()
+	return nil
+}
+
+func search(c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	f := filterFlags(fs)
+	asJSON := fs.Bool("json", false, "print JSON")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	matches, err := c.Search(*f)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(matches)
+	}
+
+	tw := table("ID", "ADDED", "GAME", "WINNER", "LOSER", "TIE", "COMMENT")
+	for _, m := range matches {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%t\t%s\n", m.ID, m.Added, m.GameName, m.Winner, m.Loser, m.IsTie, m.Comment)
+	}
+	return tw.Flush()
+}
+
+func leaderboard(c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("leaderboard", flag.ContinueOnError)
+	f := filterFlags(fs)
+	asJSON := fs.Bool("json", false, "print JSON")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	stats, err := c.Leaderboard(*f)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(stats)
+	}
+
+	tw := table("#", "NAME", "GAMES", "WINS", "TIES", "LOSSES", "WIN%")
+	for i, s := range stats {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%d\t%.1f\n", i+1, s.Name, s.Games, s.Wins, s.Ties, s.Losses, s.WinPercentage*100)
+	}
+	return tw.Flush()
+}
+
+func players(c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("players", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	players, err := c.Players()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(players)
+	}
+
+	for _, p := range players {
+		fmt.Println(p.Name)
+	}
+	return nil
+}
+
+func games(c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("games", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	games, err := c.Games()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(games)
+	}
+
+	for _, g := range games {
+		fmt.Println(g.Name)
+	}
+	return nil
+}
+
+// filterFlags adds same filters as search has
+func filterFlags(fs *flag.FlagSet) *model.Filter {
+	f := &model.Filter{}
+	fs.StringVar(&f.GameName, "game", "", "game name")
+	fs.StringVar(&f.Player1, "player", "", "player name")
+	fs.StringVar(&f.Player2, "vs", "", "opponent's name")
+	fs.IntVar(&f.LimitDays, "days", 0, "only last days")
+	fs.IntVar(&f.LimitGames, "limit", 0, "max matches")
+	return f
+}
+
+func table(header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	return tw
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func usage() {
+	fmt.Println("Usage: jumboctl <command>")
+	fmt.Println()
+	fmt.Println("  jumboctl login <url> <token>")
+	for _, name := range []string{"add", "search", "leaderboard", "players", "games"} {
+		fmt.Println("  jumboctl", commands[name].usage)
+	}
+	fmt.Println("  jumboctl completion bash|zsh")
+	fmt.Println()
+	fmt.Println("filter: [-game <game>] [-player <player>] [-vs <player>] [-days <n>] [-limit <n>]")
+}
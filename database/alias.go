@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"github.com/tuommii/jumbo/model"
+	"time"
+)
+
+/*
+**
+** #ALIAS
+**
+ */
+
+// GetAliases returns all aliases ordered by player
+func (db *SQLiteDB) GetAliases(ctx context.Context) ([]model.Alias, error) {
+	defer db.track(ctx, "GetAliases", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx, "SELECT alias, player_name FROM alias ORDER BY player_name, alias")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make([]model.Alias, 0)
+
+	for rows.Next() {
+		a := model.Alias{}
+		err := rows.Scan(&a.Alias, &a.PlayerName)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// SetAlias creates alias or points existing one to another player
+func (db *SQLiteDB) SetAlias(ctx context.Context, alias model.Alias) (int64, error) {
+	defer db.track(ctx, "SetAlias", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "INSERT OR REPLACE INTO alias(alias, player_name) VALUES(?, ?)")
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, alias.Alias, alias.PlayerName)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
+
+// DeleteAlias deletes alias, case doesn't matter
+func (db *SQLiteDB) DeleteAlias(ctx context.Context, alias string) (int64, error) {
+	defer db.track(ctx, "DeleteAlias", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "DELETE FROM alias WHERE alias = ?")
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, alias)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,512 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/logging"
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #AUDIT
+**
+ */
+
+// AddAuditEntry appends entry to audit log
+func (db *SQLiteDB) AddAuditEntry(ctx context.Context, e model.AuditEntry) error {
+	defer db.track(ctx, "AddAuditEntry", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx,
+		`INSERT INTO audit_log(actor, remote_addr, request_id, action, entity, entity_id, before, after)
+		VALUES(?,?,?,?,?,?,?,?)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, e.Actor, e.RemoteAddr, e.RequestID, e.Action, e.Entity, e.EntityID, e.Before, e.After)
+	return err
+}
+
+// GetAuditEntries returns entries matching filter, latest first
+func (db *SQLiteDB) GetAuditEntries(ctx context.Context, f model.AuditFilter) ([]model.AuditEntry, error) {
+	defer db.track(ctx, "GetAuditEntries", time.Now())
+
+	query := `SELECT id, created, actor, remote_addr, request_id, action, entity, entity_id, before, after
+		FROM audit_log WHERE 1=1`
+	args := make([]interface{}, 0)
+
+	if f.Actor != "" {
+		query += " AND actor = ?"
+		args = append(args, f.Actor)
+	}
+	if f.Action != "" {
+		query += " AND action = ?"
+		args = append(args, f.Action)
+	}
+	if f.Entity != "" {
+		query += " AND entity = ?"
+		args = append(args, f.Entity)
+	}
+	if f.EntityID > 0 {
+		query += " AND entity_id = ?"
+		args = append(args, f.EntityID)
+	}
+	if f.LimitDays > 0 {
+		query += " AND created > DATETIME('now', ?)"
+		args = append(args, fmt.Sprintf("-%d day", f.LimitDays))
+	}
+
+	query += " ORDER BY id DESC"
+
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := db.Connection.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.AuditEntry, 0)
+
+	for rows.Next() {
+		e := model.AuditEntry{}
+		err := rows.Scan(&e.ID, &e.Created, &e.Actor, &e.RemoteAddr, &e.RequestID, &e.Action, &e.Entity, &e.EntityID, &e.Before, &e.After)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Actor is who makes changes through audited database
+type Actor struct {
+	Name       string
+	RemoteAddr string
+	RequestID  string
+}
+
+// auditedDB records successful writes made by people to audit log. Reads,
+// and writes made by server itself like expiring and webhook deliveries, go
+// straight to wrapped Database.
+type auditedDB struct {
+	Database
+	actor Actor
+}
+
+// WithAudit returns db that records writes made by actor
+func WithAudit(db Database, actor Actor) Database {
+	return &auditedDB{Database: db, actor: actor}
+}
+
+func (a *auditedDB) CreatePlayer(ctx context.Context, name string) (int64, error) {
+	id, err := a.Database.CreatePlayer(ctx, name)
+	if err == nil {
+		a.record(ctx, model.ActionCreate, model.KindPlayer, int(id), nil, model.Player{ID: int(id), Name: name})
+	}
+	return id, err
+}
+
+func (a *auditedDB) DeletePlayer(ctx context.Context, name string) (int64, error) {
+	before := a.playerByName(ctx, name)
+	num, err := a.Database.DeletePlayer(ctx, name)
+	if err == nil && num > 0 && before != nil {
+		a.record(ctx, model.ActionDelete, model.KindPlayer, before.ID, before, nil)
+	}
+	return num, err
+}
+
+func (a *auditedDB) RenamePlayer(ctx context.Context, name string, newName string) (int64, error) {
+	before := a.playerByName(ctx, name)
+	num, err := a.Database.RenamePlayer(ctx, name, newName)
+	if err == nil && num > 0 && before != nil {
+		a.record(ctx, model.ActionUpdate, model.KindPlayer, before.ID, before, a.playerByName(ctx, newName))
+	}
+	return num, err
+}
+
+func (a *auditedDB) CreateGame(ctx context.Context, name string) (int64, error) {
+	id, err := a.Database.CreateGame(ctx, name)
+	if err == nil {
+		a.record(ctx, model.ActionCreate, model.KindGame, int(id), nil, model.Game{ID: int(id), Name: name})
+	}
+	return id, err
+}
+
+func (a *auditedDB) DeleteGame(ctx context.Context, name string) (int64, error) {
+	before := a.gameByName(ctx, name)
+	num, err := a.Database.DeleteGame(ctx, name)
+	if err == nil && num > 0 && before != nil {
+		a.record(ctx, model.ActionDelete, model.KindGame, before.ID, before, nil)
+	}
+	return num, err
+}
+
+func (a *auditedDB) SetGameConfirm(ctx context.Context, name string, confirm bool) (int64, error) {
+	before := a.gameByName(ctx, name)
+	num, err := a.Database.SetGameConfirm(ctx, name, confirm)
+	if err == nil && num > 0 && before != nil {
+		a.record(ctx, model.ActionUpdate, model.KindGame, before.ID, before, a.gameByName(ctx, name))
+	}
+	return num, err
+}
+
+func (a *auditedDB) CreateMatch(ctx context.Context, match model.Match) (int64, error) {
+	id, err := a.Database.CreateMatch(ctx, match)
+	if err == nil {
+		a.record(ctx, model.ActionCreate, model.KindMatch, int(id), nil, a.matchByID(ctx, int(id)))
+	}
+	return id, err
+}
+
+func (a *auditedDB) ImportMatches(ctx context.Context, imp model.Import) (int64, error) {
+	num, err := a.Database.ImportMatches(ctx, imp)
+	if err == nil {
+		summary := struct {
+			model.Import
+			Matches int64 `json:"matches"`
+		}{imp, num}
+		a.record(ctx, model.ActionImport, model.KindMatch, 0, nil, summary)
+	}
+	return num, err
+}
+
+func (a *auditedDB) DeleteMatch(ctx context.Context, id int) (int64, error) {
+	before := a.matchByID(ctx, id)
+	num, err := a.Database.DeleteMatch(ctx, id)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionDelete, model.KindMatch, id, before, nil)
+	}
+	return num, err
+}
+
+func (a *auditedDB) UpdateMatch(ctx context.Context, match model.Match, changedBy string) (int64, error) {
+	before := a.matchByID(ctx, match.ID)
+	num, err := a.Database.UpdateMatch(ctx, match, changedBy)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionUpdate, model.KindMatch, match.ID, before, a.matchByID(ctx, match.ID))
+	}
+	return num, err
+}
+
+func (a *auditedDB) ConfirmMatch(ctx context.Context, id int, player string, confirm bool) (int64, error) {
+	before := a.matchByID(ctx, id)
+	num, err := a.Database.ConfirmMatch(ctx, id, player, confirm)
+	if err == nil && num > 0 {
+		action := model.ActionConfirm
+		if !confirm {
+			action = model.ActionReject
+		}
+		a.record(ctx, action, model.KindMatch, id, before, a.matchByID(ctx, id))
+	}
+	return num, err
+}
+
+func (a *auditedDB) Restore(ctx context.Context, kind string, id int) (int64, error) {
+	num, err := a.Database.Restore(ctx, kind, id)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionRestore, kind, id, nil, a.byID(ctx, kind, id))
+	}
+	return num, err
+}
+
+func (a *auditedDB) Purge(ctx context.Context, kind string, id int) (int64, error) {
+	before := a.deleted(ctx, kind, id)
+	num, err := a.Database.Purge(ctx, kind, id)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionPurge, kind, id, before, nil)
+	}
+	return num, err
+}
+
+func (a *auditedDB) SetPlayerPassword(ctx context.Context, name string, password string) (int64, error) {
+	before := a.playerByName(ctx, name)
+	num, err := a.Database.SetPlayerPassword(ctx, name, password)
+	if err == nil && num > 0 && before != nil {
+		// Hash isn't recorded, only if password was set or removed
+		after := struct {
+			model.Player
+			Password bool `json:"password"`
+		}{*before, password != ""}
+		a.record(ctx, model.ActionUpdate, model.KindPlayer, before.ID, before, after)
+	}
+	return num, err
+}
+
+func (a *auditedDB) CreateChallenge(ctx context.Context, c model.Challenge) (int64, error) {
+	id, err := a.Database.CreateChallenge(ctx, c)
+	if err == nil {
+		a.record(ctx, model.ActionCreate, model.EntityChallenge, int(id), nil, a.challengeByID(ctx, int(id)))
+	}
+	return id, err
+}
+
+func (a *auditedDB) AnswerChallenge(ctx context.Context, id int, player string, accept bool) (int64, error) {
+	before := a.challengeByID(ctx, id)
+	num, err := a.Database.AnswerChallenge(ctx, id, player, accept)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionUpdate, model.EntityChallenge, id, before, a.challengeByID(ctx, id))
+	}
+	return num, err
+}
+
+func (a *auditedDB) SetAlias(ctx context.Context, alias model.Alias) (int64, error) {
+	before := a.aliasByName(ctx, alias.Alias)
+	num, err := a.Database.SetAlias(ctx, alias)
+	if err == nil && num > 0 {
+		action := model.ActionCreate
+		if before != nil {
+			action = model.ActionUpdate
+		}
+		a.record(ctx, action, model.EntityAlias, 0, before, alias)
+	}
+	return num, err
+}
+
+func (a *auditedDB) DeleteAlias(ctx context.Context, alias string) (int64, error) {
+	before := a.aliasByName(ctx, alias)
+	num, err := a.Database.DeleteAlias(ctx, alias)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionDelete, model.EntityAlias, 0, before, nil)
+	}
+	return num, err
+}
+
+func (a *auditedDB) CreateScheduledEvent(ctx context.Context, e model.ScheduledEvent) (int64, error) {
+	id, err := a.Database.CreateScheduledEvent(ctx, e)
+	if err == nil {
+		e.ID = int(id)
+		a.record(ctx, model.ActionCreate, model.EntityEvent, e.ID, nil, e)
+	}
+	return id, err
+}
+
+func (a *auditedDB) DeleteScheduledEvent(ctx context.Context, id int) (int64, error) {
+	before := a.eventByID(ctx, id)
+	num, err := a.Database.DeleteScheduledEvent(ctx, id)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionDelete, model.EntityEvent, id, before, nil)
+	}
+	return num, err
+}
+
+func (a *auditedDB) SetChatPlayer(ctx context.Context, userID string, player string) (int64, error) {
+	type chatUser struct {
+		UserID     string `json:"userId"`
+		PlayerName string `json:"playerName"`
+	}
+
+	previous, _ := a.Database.GetChatPlayer(ctx, userID)
+	num, err := a.Database.SetChatPlayer(ctx, userID, player)
+	if err == nil && num > 0 {
+		var before interface{}
+		action := model.ActionCreate
+		if previous != "" {
+			before = chatUser{userID, previous}
+			action = model.ActionUpdate
+		}
+		a.record(ctx, action, model.EntityChatUser, 0, before, chatUser{userID, player})
+	}
+	return num, err
+}
+
+func (a *auditedDB) CreateShareLink(ctx context.Context, l model.ShareLink) (int64, error) {
+	id, err := a.Database.CreateShareLink(ctx, l)
+	if err == nil {
+		a.record(ctx, model.ActionCreate, model.EntityShareLink, int(id), nil, a.shareLinkByID(ctx, int(id)))
+	}
+	return id, err
+}
+
+func (a *auditedDB) RevokeShareLink(ctx context.Context, id int) (int64, error) {
+	before := a.shareLinkByID(ctx, id)
+	num, err := a.Database.RevokeShareLink(ctx, id)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionUpdate, model.EntityShareLink, id, before, a.shareLinkByID(ctx, id))
+	}
+	return num, err
+}
+
+// Webhook's secret isn't in its JSON, so it stays out of audit log
+func (a *auditedDB) CreateWebhook(ctx context.Context, w model.Webhook) (int64, error) {
+	id, err := a.Database.CreateWebhook(ctx, w)
+	if err == nil {
+		w.ID = int(id)
+		a.record(ctx, model.ActionCreate, model.EntityWebhook, w.ID, nil, w)
+	}
+	return id, err
+}
+
+func (a *auditedDB) DeleteWebhook(ctx context.Context, id int) (int64, error) {
+	before := a.webhookByID(ctx, id)
+	num, err := a.Database.DeleteWebhook(ctx, id)
+	if err == nil && num > 0 {
+		a.record(ctx, model.ActionDelete, model.EntityWebhook, id, before, nil)
+	}
+	return num, err
+}
+
+// record appends entry, failing to do so doesn't undo the write. Write is
+// already done, so entry is added even if request was cancelled.
+func (a *auditedDB) record(ctx context.Context, action string, entity string, id int, before interface{}, after interface{}) {
+	e := model.AuditEntry{
+		Actor:      a.actor.Name,
+		RemoteAddr: a.actor.RemoteAddr,
+		RequestID:  a.actor.RequestID,
+		Action:     action,
+		Entity:     entity,
+		EntityID:   id,
+		Before:     snapshot(before),
+		After:      snapshot(after),
+	}
+
+	err := a.Database.AddAuditEntry(context.WithoutCancel(ctx), e)
+	if err != nil {
+		logging.FromContext(ctx).Error("audit entry failed", "err", err)
+	}
+}
+
+func (a *auditedDB) playerByName(ctx context.Context, name string) *model.Player {
+	players, err := a.Database.GetPlayers(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range players {
+		if players[i].Name == name {
+			return &players[i]
+		}
+	}
+	return nil
+}
+
+func (a *auditedDB) gameByName(ctx context.Context, name string) *model.Game {
+	games, err := a.Database.GetGames(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range games {
+		if games[i].Name == name {
+			return &games[i]
+		}
+	}
+	return nil
+}
+
+func (a *auditedDB) matchByID(ctx context.Context, id int) *model.Match {
+	match, err := a.Database.GetMatch(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return &match
+}
+
+func (a *auditedDB) challengeByID(ctx context.Context, id int) *model.Challenge {
+	c, err := a.Database.GetChallenge(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return &c
+}
+
+func (a *auditedDB) aliasByName(ctx context.Context, alias string) *model.Alias {
+	aliases, err := a.Database.GetAliases(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range aliases {
+		if strings.EqualFold(aliases[i].Alias, alias) {
+			return &aliases[i]
+		}
+	}
+	return nil
+}
+
+func (a *auditedDB) eventByID(ctx context.Context, id int) *model.ScheduledEvent {
+	e, err := a.Database.GetScheduledEvent(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return &e
+}
+
+func (a *auditedDB) shareLinkByID(ctx context.Context, id int) *model.ShareLink {
+	l, err := a.Database.GetShareLink(ctx, id)
+	if err != nil {
+		return nil
+	}
+	return &l
+}
+
+func (a *auditedDB) webhookByID(ctx context.Context, id int) *model.Webhook {
+	webhooks, err := a.Database.GetWebhooks(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range webhooks {
+		if webhooks[i].ID == id {
+			return &webhooks[i]
+		}
+	}
+	return nil
+}
+
+// byID returns snapshot of restored row
+func (a *auditedDB) byID(ctx context.Context, kind string, id int) interface{} {
+	switch kind {
+	case model.KindMatch:
+		return a.matchByID(ctx, id)
+	case model.KindPlayer:
+		players, err := a.Database.GetPlayers(ctx)
+		if err != nil {
+			return nil
+		}
+		for _, p := range players {
+			if p.ID == id {
+				return p
+			}
+		}
+	case model.KindGame:
+		games, err := a.Database.GetGames(ctx)
+		if err != nil {
+			return nil
+		}
+		for _, g := range games {
+			if g.ID == id {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+func (a *auditedDB) deleted(ctx context.Context, kind string, id int) *model.Deleted {
+	deleted, err := a.Database.GetDeleted(ctx)
+	if err != nil {
+		return nil
+	}
+	for i := range deleted {
+		if deleted[i].Kind == kind && deleted[i].ID == id {
+			return &deleted[i]
+		}
+	}
+	return nil
+}
+
+// snapshot returns entity as JSON
+func snapshot(entity interface{}) string {
+	b, err := json.Marshal(entity)
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+/*
+**
+** #BACKUP
+**
+ */
+
+// Backup file names are prefix + time + suffix, so they sort by age
+const (
+	backupPrefix     = "jumbo-"
+	backupSuffix     = ".db"
+	backupTimeFormat = "20060102-150405"
+	// Pages copied per step, lock is released between steps
+	backupStepPages = 256
+)
+
+// Backup copies consistent snapshot of database to dest while it's in use
+func (db *SQLiteDB) Backup(ctx context.Context, dest string) error {
+	defer db.track(ctx, "Backup", time.Now())
+
+	conn, err := db.Connection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) error {
+		src, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("backup: unexpected driver connection %T", driverConn)
+		}
+		return copyDB(ctx, dest, src)
+	})
+}
+
+// copyDB runs backup from src to new file dest, cancelling ctx stops it
+// between steps
+func copyDB(ctx context.Context, dest string, src *sqlite3.SQLiteConn) error {
+	driver := &sqlite3.SQLiteDriver{}
+	dc, err := driver.Open(dest)
+	if err != nil {
+		return err
+	}
+	destConn := dc.(*sqlite3.SQLiteConn)
+	defer destConn.Close()
+
+	b, err := destConn.Backup("main", src, "main")
+	if err != nil {
+		return err
+	}
+
+	for {
+		done, err := b.Step(backupStepPages)
+		if err != nil {
+			b.Close()
+			return err
+		}
+		if done {
+			break
+		}
+		if ctx.Err() != nil {
+			b.Close()
+			return ctx.Err()
+		}
+	}
+
+	return b.Finish()
+}
+
+// BackupTo writes timestamped backup to dir and removes all but keep newest
+// backups. Returns path of new backup.
+func BackupTo(ctx context.Context, db Database, dir string, keep int) (string, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(dir, backupPrefix+time.Now().UTC().Format(backupTimeFormat)+backupSuffix)
+
+	err = db.Backup(ctx, dest)
+	if err != nil {
+		os.Remove(dest)
+		return "", err
+	}
+
+	return dest, RotateBackups(dir, keep)
+}
+
+// RotateBackups removes all but keep newest backups from dir. Zero or less
+// keeps everything.
+func RotateBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return err
+	}
+
+	for i := keep; i < len(backups); i++ {
+		err := os.Remove(filepath.Join(dir, backups[i]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListBackups returns backup file names in dir, newest first
+func ListBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]string, 0)
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) {
+			backups = append(backups, name)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// Tables backup must have to be restored
+var backupTables = []string{"player", "game", "match"}
+
+// RestoreBackup replaces database file dest with backup src. Backup must
+// pass integrity check and not be newer than this version's schema, older
+// ones are migrated on next start. Server must not be running.
+func RestoreBackup(src string, dest string) error {
+	_, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	// Path is escaped so ? and # in it aren't read as URI parameters, and
+	// absolute so first directory isn't read as host
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	uri := url.URL{Scheme: "file", Path: abs, RawQuery: "mode=ro"}
+	backup, err := sql.Open("sqlite3", uri.String())
+	if err != nil {
+		return err
+	}
+
+	version, err := schemaVersion(backup)
+	if err != nil {
+		backup.Close()
+		return err
+	}
+	if version > SchemaVersion {
+		backup.Close()
+		return fmt.Errorf("backup schema version %d is newer than supported %d", version, SchemaVersion)
+	}
+	// Version 0 is what any SQLite file has, jumbo's database has at least
+	// first migration
+	if version == 0 {
+		backup.Close()
+		return fmt.Errorf("%s is not a jumbo database, schema version is 0", src)
+	}
+
+	for _, table := range backupTables {
+		var count int
+		err = backup.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+		if err != nil {
+			backup.Close()
+			return err
+		}
+		if count == 0 {
+			backup.Close()
+			return fmt.Errorf("%s is not a jumbo database, table %s is missing", src, table)
+		}
+	}
+
+	var check string
+	err = backup.QueryRow("PRAGMA integrity_check").Scan(&check)
+	if err != nil {
+		backup.Close()
+		return err
+	}
+	if check != "ok" {
+		backup.Close()
+		return fmt.Errorf("backup failed integrity check: %s", check)
+	}
+
+	// Copy next to dest first so swap is a rename
+	tmp := dest + ".restore"
+	os.Remove(tmp)
+
+	conn, err := backup.Conn(context.Background())
+	if err != nil {
+		backup.Close()
+		return err
+	}
+	err = conn.Raw(func(driverConn interface{}) error {
+		return copyDB(context.Background(), tmp, driverConn.(*sqlite3.SQLiteConn))
+	})
+	conn.Close()
+	backup.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// Journal of replaced file must not be applied to restored one
+	os.Remove(dest + "-journal")
+	os.Remove(dest + "-wal")
+	os.Remove(dest + "-shm")
+
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+/*
+**
+** #CHAT
+**
+ */
+
+// ErrPlayerClaimed is returned when other chat user already is the player
+var ErrPlayerClaimed = errors.New("player is already claimed by another chat user")
+
+// GetChatPlayer returns player chat user is, empty if user hasn't told
+func (db *SQLiteDB) GetChatPlayer(ctx context.Context, userID string) (string, error) {
+	defer db.track(ctx, "GetChatPlayer", time.Now())
+
+	var player string
+	err := db.Connection.QueryRowContext(ctx, "SELECT player_name FROM chat_user WHERE user_id = ?", userID).Scan(&player)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return player, err
+}
+
+// SetChatPlayer maps chat user to player, replacing earlier one. Returns
+// ErrPlayerClaimed if another chat user is the player.
+func (db *SQLiteDB) SetChatPlayer(ctx context.Context, userID string, player string) (int64, error) {
+	defer db.track(ctx, "SetChatPlayer", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM chat_user WHERE player_name = ? AND user_id != ?", player, userID).Scan(&count)
+	if err != nil {
+		return -1, err
+	}
+	if count > 0 {
+		return -1, ErrPlayerClaimed
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO chat_user(user_id, player_name) VALUES(?, ?)", userID, player)
+	if err != nil {
+		return -1, err
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+	return num, tx.Commit()
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #CONFIRMATION
+**
+ */
+
+// ErrNotOpponent is returned when someone else than opponent tries to
+// confirm a match
+var ErrNotOpponent = errors.New("only opponent can confirm match")
+
+// ErrNotIdentified is returned when match needing confirmation is reported
+// by no one, there would be no one to not confirm it
+var ErrNotIdentified = errors.New("identify as player to report matches of this game")
+
+// SetGameConfirm sets if game's new matches need confirmation
+func (db *SQLiteDB) SetGameConfirm(ctx context.Context, name string, confirm bool) (int64, error) {
+	defer db.track(ctx, "SetGameConfirm", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "UPDATE game SET confirm = ? WHERE name = ? AND deleted_at IS NULL")
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, confirm, name)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
+
+// GetPendingMatches returns unconfirmed matches player played or reported
+func (db *SQLiteDB) GetPendingMatches(ctx context.Context, player string) ([]model.Match, error) {
+	defer db.track(ctx, "GetPendingMatches", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx,
+		`SELECT id, game_name, is_tie, winner, loser, comment, added, status, reported_by FROM match
+		WHERE status = ? AND deleted_at IS NULL AND (winner = ? OR loser = ? OR reported_by = ?) ORDER BY added`,
+		model.MatchPending, player, player, player,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make([]model.Match, 0)
+
+	for rows.Next() {
+		match := model.Match{}
+		err := rows.Scan(
+			&match.ID,
+			&match.GameName,
+			&match.IsTie,
+			&match.Winner,
+			&match.Loser,
+			&match.Comment,
+			&match.Added,
+			&match.Status,
+			&match.ReportedBy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// ConfirmMatch confirms or rejects pending match. Player must have played
+// the match and not be the one who reported it.
+func (db *SQLiteDB) ConfirmMatch(ctx context.Context, id int, player string, confirm bool) (int64, error) {
+	defer db.track(ctx, "ConfirmMatch", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	match, err := getPendingMatch(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	if player == match.ReportedBy || (player != match.Winner && player != match.Loser) {
+		return -1, ErrNotOpponent
+	}
+
+	num, err := setMatchStatus(ctx, tx, match, confirm)
+	if err != nil {
+		return -1, err
+	}
+
+	return num, tx.Commit()
+}
+
+// ExpirePendingMatches confirms or rejects matches pending longer than
+// timeout, returns their IDs
+func (db *SQLiteDB) ExpirePendingMatches(ctx context.Context, timeout time.Duration, confirm bool) ([]int, error) {
+	defer db.track(ctx, "ExpirePendingMatches", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	age := fmt.Sprintf("-%d seconds", int(timeout.Seconds()))
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM match WHERE status = ? AND deleted_at IS NULL AND added < DATETIME('now', ?)", model.MatchPending, age)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		match, err := getPendingMatch(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = setMatchStatus(ctx, tx, match, confirm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, tx.Commit()
+}
+
+// setMatchStatus confirms or rejects match, confirmed match resolves ladder
+// challenge
+func setMatchStatus(ctx context.Context, tx *sql.Tx, match model.Match, confirm bool) (int64, error) {
+	status := model.MatchRejected
+	if confirm {
+		status = model.MatchConfirmed
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE match SET status = ? WHERE id = ?", status, match.ID)
+	if err != nil {
+		return -1, err
+	}
+
+	if confirm {
+		err = resolveChallenge(ctx, tx, match, int64(match.ID))
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return res.RowsAffected()
+}
+
+func getPendingMatch(ctx context.Context, tx *sql.Tx, id int) (model.Match, error) {
+	match := model.Match{}
+	err := tx.QueryRowContext(ctx,
+		"SELECT id, game_name, is_tie, winner, loser, reported_by FROM match WHERE id = ? AND status = ? AND deleted_at IS NULL",
+		id, model.MatchPending,
+	).Scan(&match.ID, &match.GameName, &match.IsTie, &match.Winner, &match.Loser, &match.ReportedBy)
+	return match, err
+}
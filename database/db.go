@@ -1,20 +1,90 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"time"
+
 	"github.com/tuommii/jumbo/model"
 )
 
-// Database ...
+// Database ... Every method takes context of request or job it runs for.
 type Database interface {
-	GetPlayers() ([]model.Player, error)
-	CreatePlayer(name string) (int64, error)
-	DeletePlayer(name string) (int64, error)
+	GetPlayers(ctx context.Context) ([]model.Player, error)
+	CreatePlayer(ctx context.Context, name string) (int64, error)
+	DeletePlayer(ctx context.Context, name string) (int64, error)
+	RenamePlayer(ctx context.Context, name string, newName string) (int64, error)
+
+	GetGames(ctx context.Context) ([]model.Game, error)
+	CreateGame(ctx context.Context, name string) (int64, error)
+	DeleteGame(ctx context.Context, name string) (int64, error)
+	SetGameConfirm(ctx context.Context, name string, confirm bool) (int64, error)
+
+	GetMatches(ctx context.Context, f model.Filter) ([]model.Match, error)
+	EachMatch(ctx context.Context, f model.Filter, fn func(model.Match) error) error
+	CreateMatch(ctx context.Context, match model.Match) (int64, error)
+	DeleteMatch(ctx context.Context, id int) (int64, error)
+	ImportMatches(ctx context.Context, imp model.Import) (int64, error)
+	GetMatch(ctx context.Context, id int) (model.Match, error)
+	UpdateMatch(ctx context.Context, match model.Match, changedBy string) (int64, error)
+	GetMatchHistory(ctx context.Context, id int) ([]model.MatchChange, error)
+
+	GetPendingMatches(ctx context.Context, player string) ([]model.Match, error)
+	ConfirmMatch(ctx context.Context, id int, player string, confirm bool) (int64, error)
+	ExpirePendingMatches(ctx context.Context, timeout time.Duration, confirm bool) ([]int, error)
+
+	GetDeleted(ctx context.Context) ([]model.Deleted, error)
+	Restore(ctx context.Context, kind string, id int) (int64, error)
+	Purge(ctx context.Context, kind string, id int) (int64, error)
+
+	AddAuditEntry(ctx context.Context, e model.AuditEntry) error
+	GetAuditEntries(ctx context.Context, f model.AuditFilter) ([]model.AuditEntry, error)
+
+	GetLadder(ctx context.Context, gameName string) ([]model.Rung, error)
+	GetOpenChallenges(ctx context.Context) ([]model.Challenge, error)
+	GetChallenge(ctx context.Context, id int) (model.Challenge, error)
+	CreateChallenge(ctx context.Context, c model.Challenge) (int64, error)
+	AnswerChallenge(ctx context.Context, id int, player string, accept bool) (int64, error)
+	ExpireChallenges(ctx context.Context) (int64, error)
+
+	GetAliases(ctx context.Context) ([]model.Alias, error)
+	SetAlias(ctx context.Context, alias model.Alias) (int64, error)
+	DeleteAlias(ctx context.Context, alias string) (int64, error)
+
+	GetScheduledEvents(ctx context.Context, f model.EventFilter) ([]model.ScheduledEvent, error)
+	GetScheduledEvent(ctx context.Context, id int) (model.ScheduledEvent, error)
+	CreateScheduledEvent(ctx context.Context, e model.ScheduledEvent) (int64, error)
+	DeleteScheduledEvent(ctx context.Context, id int) (int64, error)
+
+	GetChatPlayer(ctx context.Context, userID string) (string, error)
+	SetChatPlayer(ctx context.Context, userID string, player string) (int64, error)
+
+	SetPlayerPassword(ctx context.Context, name string, password string) (int64, error)
+	CheckPlayerPassword(ctx context.Context, name string, password string) (bool, error)
+	GetSecret(ctx context.Context, name string) ([]byte, error)
+
+	GetShareLinks(ctx context.Context) ([]model.ShareLink, error)
+	GetShareLink(ctx context.Context, id int) (model.ShareLink, error)
+	CreateShareLink(ctx context.Context, l model.ShareLink) (int64, error)
+	RevokeShareLink(ctx context.Context, id int) (int64, error)
+
+	GetWebhooks(ctx context.Context) ([]model.Webhook, error)
+	CreateWebhook(ctx context.Context, w model.Webhook) (int64, error)
+	DeleteWebhook(ctx context.Context, id int) (int64, error)
+	QueueEvent(ctx context.Context, event string, payload string) (int64, error)
+	QueueDelivery(ctx context.Context, webhookID int, event string, payload string) (int64, error)
+	GetDueDeliveries(ctx context.Context, limit int) ([]model.Delivery, error)
+	GetDeliveries(ctx context.Context, limit int) ([]model.Delivery, error)
+	UpdateDelivery(ctx context.Context, d model.Delivery, retryIn time.Duration) (int64, error)
+	RetryDelivery(ctx context.Context, id int) (int64, error)
+
+	Backup(ctx context.Context, dest string) error
 
-	GetGames() ([]model.Game, error)
-	CreateGame(name string) (int64, error)
-	DeleteGame(name string) (int64, error)
+	Ping(ctx context.Context) error
+	Version(ctx context.Context) (int, error)
+	DiskFree(ctx context.Context) (uint64, error)
 
-	GetMatches(f model.Filter) ([]model.Match, error)
-	CreateMatch(match model.Match) (int64, error)
-	DeleteMatch(id int) (int64, error)
+	CountMatches(ctx context.Context, days int) (map[string]int, error)
+	GetRatings(ctx context.Context) ([]model.Rating, error)
+	PoolStats(ctx context.Context) sql.DBStats
 }
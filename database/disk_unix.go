@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package database
+
+import "syscall"
+
+// diskFree returns bytes available to unprivileged user in dir
+func diskFree(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(dir, &st)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
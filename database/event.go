@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #EVENT
+**
+ */
+
+// GetScheduledEvents returns events matching filter ordered by start time
+func (db *SQLiteDB) GetScheduledEvents(ctx context.Context, f model.EventFilter) ([]model.ScheduledEvent, error) {
+	defer db.track(ctx, "GetScheduledEvents", time.Now())
+
+	where := " WHERE ends > DATETIME('now', ?)"
+	args := []interface{}{fmt.Sprintf("-%d day", f.PastDays)}
+
+	if f.GameName != "" {
+		where += " AND game_name = ?"
+		args = append(args, f.GameName)
+	}
+	if f.Player != "" {
+		where += " AND id IN (SELECT event_id FROM scheduled_event_player WHERE player = ?)"
+		args = append(args, f.Player)
+	}
+
+	return db.queryScheduledEvents(ctx, where, args)
+}
+
+// GetScheduledEvent returns event by ID, also ended one
+func (db *SQLiteDB) GetScheduledEvent(ctx context.Context, id int) (model.ScheduledEvent, error) {
+	defer db.track(ctx, "GetScheduledEvent", time.Now())
+
+	events, err := db.queryScheduledEvents(ctx, " WHERE id = ?", []interface{}{id})
+	if err != nil {
+		return model.ScheduledEvent{}, err
+	}
+	if len(events) == 0 {
+		return model.ScheduledEvent{}, sql.ErrNoRows
+	}
+	return events[0], nil
+}
+
+// queryScheduledEvents returns events selected by where with their players
+func (db *SQLiteDB) queryScheduledEvents(ctx context.Context, where string, args []interface{}) ([]model.ScheduledEvent, error) {
+	rows, err := db.Connection.QueryContext(ctx,
+		"SELECT id, game_name, title, starts, ends, location, created FROM scheduled_event"+where+" ORDER BY starts", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.ScheduledEvent, 0)
+	index := make(map[int]int)
+
+	for rows.Next() {
+		e := model.ScheduledEvent{Players: make([]string, 0)}
+		err := rows.Scan(&e.ID, &e.GameName, &e.Title, &e.Starts, &e.Ends, &e.Location, &e.Created)
+		if err != nil {
+			return nil, err
+		}
+		index[e.ID] = len(events)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(events) == 0 {
+		return events, nil
+	}
+
+	// Players of selected events at once, in order they were added
+	players, err := db.Connection.QueryContext(ctx,
+		"SELECT event_id, player FROM scheduled_event_player WHERE event_id IN (SELECT id FROM scheduled_event"+where+") ORDER BY rowid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer players.Close()
+
+	for players.Next() {
+		var id int
+		var player string
+		err := players.Scan(&id, &player)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			events[i].Players = append(events[i].Players, player)
+		}
+	}
+	return events, players.Err()
+}
+
+// CreateScheduledEvent adds event with its players, starts and ends are
+// RFC3339
+func (db *SQLiteDB) CreateScheduledEvent(ctx context.Context, e model.ScheduledEvent) (int64, error) {
+	defer db.track(ctx, "CreateScheduledEvent", time.Now())
+
+	starts, err := time.Parse(time.RFC3339, e.Starts)
+	if err != nil {
+		return -1, err
+	}
+	ends, err := time.Parse(time.RFC3339, e.Ends)
+	if err != nil {
+		return -1, err
+	}
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO scheduled_event(game_name, title, starts, ends, location) VALUES(?, ?, ?, ?, ?)",
+		e.GameName, e.Title, starts.UTC().Format(timestampFormat), ends.UTC().Format(timestampFormat), e.Location,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	for _, player := range e.Players {
+		_, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO scheduled_event_player(event_id, player) VALUES(?, ?)", id, player)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+// DeleteScheduledEvent deletes event and its players
+func (db *SQLiteDB) DeleteScheduledEvent(ctx context.Context, id int) (int64, error) {
+	defer db.track(ctx, "DeleteScheduledEvent", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM scheduled_event_player WHERE event_id = ?", id)
+	if err != nil {
+		return -1, err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM scheduled_event WHERE id = ?", id)
+	if err != nil {
+		return -1, err
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+	return num, tx.Commit()
+}
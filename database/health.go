@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+**
+** #HEALTH
+**
+ */
+
+// Ping checks that database can be reached
+func (db *SQLiteDB) Ping(ctx context.Context) error {
+	defer db.track(ctx, "Ping", time.Now())
+
+	return db.Connection.PingContext(ctx)
+}
+
+// DiskFree returns bytes available on file system of database file
+func (db *SQLiteDB) DiskFree(ctx context.Context) (uint64, error) {
+	defer db.track(ctx, "DiskFree", time.Now())
+
+	// Strip URI form and its parameters, file:jumbo.db?mode=ro
+	name := strings.TrimPrefix(db.File, "file:")
+	if i := strings.IndexByte(name, '?'); i >= 0 {
+		name = name[:i]
+	}
+	return diskFree(filepath.Dir(name))
+}
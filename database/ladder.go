@@ -0,0 +1,352 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #LADDER
+**
+ */
+
+const challengeColumns = "id, game_name, challenger, opponent, status, COALESCE(match_id, 0), created, deadline"
+
+// GetLadder returns game's ladder from top to bottom
+func (db *SQLiteDB) GetLadder(ctx context.Context, gameName string) ([]model.Rung, error) {
+	defer db.track(ctx, "GetLadder", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx, "SELECT game_name, rank, player FROM ladder WHERE game_name = ? ORDER BY rank", gameName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ladder := make([]model.Rung, 0)
+
+	for rows.Next() {
+		rung := model.Rung{}
+		err := rows.Scan(&rung.GameName, &rung.Rank, &rung.Player)
+		if err != nil {
+			return nil, err
+		}
+
+		ladder = append(ladder, rung)
+	}
+
+	return ladder, rows.Err()
+}
+
+// GetOpenChallenges returns challenges waiting for answer or result
+func (db *SQLiteDB) GetOpenChallenges(ctx context.Context) ([]model.Challenge, error) {
+	defer db.track(ctx, "GetOpenChallenges", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx,
+		"SELECT "+challengeColumns+" FROM challenge WHERE status IN (?, ?) ORDER BY deadline",
+		model.ChallengePending, model.ChallengeAccepted,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	challenges := make([]model.Challenge, 0)
+
+	for rows.Next() {
+		c := model.Challenge{}
+		err := rows.Scan(&c.ID, &c.GameName, &c.Challenger, &c.Opponent, &c.Status, &c.MatchID, &c.Created, &c.Deadline)
+		if err != nil {
+			return nil, err
+		}
+
+		challenges = append(challenges, c)
+	}
+
+	return challenges, rows.Err()
+}
+
+// CreateChallenge creates new challenge. Players not in ladder are added to
+// bottom, opponent first.
+func (db *SQLiteDB) CreateChallenge(ctx context.Context, c model.Challenge) (int64, error) {
+	defer db.track(ctx, "CreateChallenge", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	var players int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM player WHERE name IN (?, ?) AND deleted_at IS NULL",
+		c.Challenger, c.Opponent,
+	).Scan(&players)
+	if err != nil {
+		return -1, err
+	}
+	if players != 2 {
+		return -1, model.ErrChallengePlayer
+	}
+
+	opponentRank, err := joinLadder(ctx, tx, c.GameName, c.Opponent)
+	if err != nil {
+		return -1, err
+	}
+
+	challengerRank, err := joinLadder(ctx, tx, c.GameName, c.Challenger)
+	if err != nil {
+		return -1, err
+	}
+
+	if !model.CanChallenge(challengerRank, opponentRank) {
+		return -1, model.ErrChallengeRange
+	}
+
+	var open int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM challenge WHERE game_name = ? AND status IN (?, ?)
+		AND (challenger IN (?, ?) OR opponent IN (?, ?))`,
+		c.GameName, model.ChallengePending, model.ChallengeAccepted,
+		c.Challenger, c.Opponent, c.Challenger, c.Opponent,
+	).Scan(&open)
+	if err != nil {
+		return -1, err
+	}
+	if open > 0 {
+		return -1, model.ErrChallengeOpen
+	}
+
+	deadline := fmt.Sprintf("+%d seconds", int(model.ChallengeDeadline.Seconds()))
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO challenge(game_name, challenger, opponent, status, deadline) VALUES(?,?,?,?,DATETIME('now', ?))",
+		c.GameName, c.Challenger, c.Opponent, model.ChallengePending, deadline,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	return id, tx.Commit()
+}
+
+// AnswerChallenge accepts or declines pending challenge. Only opponent
+// answers, declining forfeits.
+func (db *SQLiteDB) AnswerChallenge(ctx context.Context, id int, player string, accept bool) (int64, error) {
+	defer db.track(ctx, "AnswerChallenge", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	c, err := getChallenge(ctx, tx, id)
+	if err == sql.ErrNoRows {
+		return -1, model.ErrNoChallenge
+	}
+	if err != nil {
+		return -1, err
+	}
+	if player != c.Opponent {
+		return -1, model.ErrNotChallenged
+	}
+	if c.Status != model.ChallengePending {
+		return 0, nil
+	}
+
+	status := model.ChallengeAccepted
+	if !accept {
+		status = model.ChallengeDeclined
+		err = swapRanks(ctx, tx, c.GameName, c.Challenger, c.Opponent)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE challenge SET status = ? WHERE id = ?", status, id)
+	if err != nil {
+		return -1, err
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+
+	return num, tx.Commit()
+}
+
+// ExpireChallenges forfeits open challenges past deadline, challengers
+// take opponents' ranks
+func (db *SQLiteDB) ExpireChallenges(ctx context.Context) (int64, error) {
+	defer db.track(ctx, "ExpireChallenges", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT "+challengeColumns+" FROM challenge WHERE status IN (?, ?) AND deadline < DATETIME('now')",
+		model.ChallengePending, model.ChallengeAccepted,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	expired := make([]model.Challenge, 0)
+	for rows.Next() {
+		c := model.Challenge{}
+		err := rows.Scan(&c.ID, &c.GameName, &c.Challenger, &c.Opponent, &c.Status, &c.MatchID, &c.Created, &c.Deadline)
+		if err != nil {
+			rows.Close()
+			return -1, err
+		}
+		expired = append(expired, c)
+	}
+	rows.Close()
+
+	for _, c := range expired {
+		err = swapRanks(ctx, tx, c.GameName, c.Challenger, c.Opponent)
+		if err != nil {
+			return -1, err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE challenge SET status = ? WHERE id = ?", model.ChallengeForfeited, c.ID)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return int64(len(expired)), tx.Commit()
+}
+
+// resolveChallenge closes open challenge between match's players and swaps
+// ranks if challenger won
+func resolveChallenge(ctx context.Context, tx *sql.Tx, match model.Match, matchID int64) error {
+	c := model.Challenge{}
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, challenger, opponent FROM challenge WHERE game_name = ? AND status IN (?, ?)
+		AND ((challenger = ? AND opponent = ?) OR (challenger = ? AND opponent = ?))`,
+		match.GameName, model.ChallengePending, model.ChallengeAccepted,
+		match.Winner, match.Loser, match.Loser, match.Winner,
+	).Scan(&c.ID, &c.Challenger, &c.Opponent)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !match.IsTie && match.Winner == c.Challenger {
+		err = swapRanks(ctx, tx, match.GameName, c.Challenger, c.Opponent)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE challenge SET status = ?, match_id = ? WHERE id = ?", model.ChallengePlayed, matchID, c.ID)
+	return err
+}
+
+// reresolveChallenge fixes ladder when edit changes whether challenger won
+// the match that played challenge. Ranks are swapped back, or swapped now.
+func reresolveChallenge(ctx context.Context, tx *sql.Tx, old model.Match, match model.Match) error {
+	c := model.Challenge{}
+	err := tx.QueryRowContext(ctx,
+		"SELECT id, game_name, challenger, opponent FROM challenge WHERE match_id = ? AND status = ?",
+		match.ID, model.ChallengePlayed,
+	).Scan(&c.ID, &c.GameName, &c.Challenger, &c.Opponent)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	won := func(m model.Match) bool {
+		return m.GameName == c.GameName && !m.IsTie && m.Winner == c.Challenger && m.Loser == c.Opponent
+	}
+
+	switch {
+	case won(old) && !won(match):
+		// Opponent is now below, this moves it back up
+		return swapRanks(ctx, tx, c.GameName, c.Opponent, c.Challenger)
+	case !won(old) && won(match):
+		return swapRanks(ctx, tx, c.GameName, c.Challenger, c.Opponent)
+	}
+	return nil
+}
+
+// joinLadder returns player's rank, adds player to bottom if needed
+func joinLadder(ctx context.Context, tx *sql.Tx, gameName string, player string) (int, error) {
+	var rank int
+	err := tx.QueryRowContext(ctx, "SELECT rank FROM ladder WHERE game_name = ? AND player = ?", gameName, player).Scan(&rank)
+	if err == nil {
+		return rank, nil
+	}
+	if err != sql.ErrNoRows {
+		return -1, err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(rank), 0) + 1 FROM ladder WHERE game_name = ?", gameName).Scan(&rank)
+	if err != nil {
+		return -1, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO ladder(game_name, player, rank) VALUES(?,?,?)", gameName, player, rank)
+	return rank, err
+}
+
+// swapRanks moves challenger to opponent's rank and vice versa, if
+// challenger is still below
+func swapRanks(ctx context.Context, tx *sql.Tx, gameName string, challenger string, opponent string) error {
+	var challengerRank, opponentRank int
+
+	err := tx.QueryRowContext(ctx, "SELECT rank FROM ladder WHERE game_name = ? AND player = ?", gameName, challenger).Scan(&challengerRank)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT rank FROM ladder WHERE game_name = ? AND player = ?", gameName, opponent).Scan(&opponentRank)
+	if err != nil {
+		return err
+	}
+
+	if challengerRank < opponentRank {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE ladder SET rank = ? WHERE game_name = ? AND player = ?", opponentRank, gameName, challenger)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE ladder SET rank = ? WHERE game_name = ? AND player = ?", challengerRank, gameName, opponent)
+	return err
+}
+
+// GetChallenge returns challenge by ID
+func (db *SQLiteDB) GetChallenge(ctx context.Context, id int) (model.Challenge, error) {
+	defer db.track(ctx, "GetChallenge", time.Now())
+
+	return getChallenge(ctx, db.Connection, id)
+}
+
+func getChallenge(ctx context.Context, q rowQuerier, id int) (model.Challenge, error) {
+	c := model.Challenge{}
+	err := q.QueryRowContext(ctx, "SELECT "+challengeColumns+" FROM challenge WHERE id = ?", id).Scan(
+		&c.ID, &c.GameName, &c.Challenger, &c.Opponent, &c.Status, &c.MatchID, &c.Created, &c.Deadline,
+	)
+	return c, err
+}
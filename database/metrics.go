@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tuommii/jumbo/metrics"
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #METRICS
+**
+ */
+
+var queryDuration = metrics.NewHistogramVec(
+	"jumbo_db_query_duration_seconds",
+	"Duration of database calls by Database method.",
+	metrics.DefaultBuckets,
+	"method",
+)
+
+func init() {
+	metrics.Default.Register(queryDuration)
+}
+
+// CountMatches returns number of confirmed matches per game, in last days
+// if days is more than zero
+func (db *SQLiteDB) CountMatches(ctx context.Context, days int) (map[string]int, error) {
+	defer db.track(ctx, "CountMatches", time.Now())
+
+	query := "SELECT game_name, COUNT(*) FROM match WHERE status = ? AND deleted_at IS NULL"
+	args := []interface{}{model.MatchConfirmed}
+	if days > 0 {
+		query += " AND added > DATETIME('now', ?)"
+		args = append(args, fmt.Sprintf("-%d day", days))
+	}
+	query += " GROUP BY game_name"
+
+	rows, err := db.Connection.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var game string
+		var count int
+		err := rows.Scan(&game, &count)
+		if err != nil {
+			return nil, err
+		}
+		counts[game] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetRatings returns Elo ratings computed from all confirmed matches
+func (db *SQLiteDB) GetRatings(ctx context.Context) ([]model.Rating, error) {
+	defer db.track(ctx, "GetRatings", time.Now())
+
+	matches, err := db.getMatches(ctx, model.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	return model.Ratings(matches), nil
+}
+
+// PoolStats returns connection pool statistics, it doesn't query database
+func (db *SQLiteDB) PoolStats(ctx context.Context) sql.DBStats {
+	return db.Connection.Stats()
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations change schema created by schema const. Index+1 is the schema
+// version stored in PRAGMA user_version after migration has run.
+var migrations = []string{
+	// 1: match confirmation
+	`ALTER TABLE game ADD COLUMN confirm BOOLEAN NOT NULL DEFAULT 0;
+	ALTER TABLE match ADD COLUMN status TEXT NOT NULL DEFAULT 'confirmed';
+	ALTER TABLE match ADD COLUMN reported_by TEXT NOT NULL DEFAULT '';`,
+	// 2: soft delete
+	`ALTER TABLE player ADD COLUMN deleted_at TIMESTAMP;
+	ALTER TABLE game ADD COLUMN deleted_at TIMESTAMP;
+	ALTER TABLE match ADD COLUMN deleted_at TIMESTAMP;`,
+	// 3: optional scores
+	`ALTER TABLE match ADD COLUMN winner_score INTEGER;
+	ALTER TABLE match ADD COLUMN loser_score INTEGER;`,
+	// 4: player passwords
+	`ALTER TABLE player ADD COLUMN password TEXT NOT NULL DEFAULT '';`,
+}
+
+// SchemaVersion is version of fully migrated database
+var SchemaVersion = len(migrations)
+
+// migrate runs migrations newer than database's version, each in own
+// transaction
+func migrate(db *sql.DB) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	if version > SchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported %d", version, SchemaVersion)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(migrations[i])
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %v", i+1, err)
+		}
+
+		// PRAGMA doesn't take parameters
+		_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// Version returns database's schema version
+func (db *SQLiteDB) Version(ctx context.Context) (int, error) {
+	defer db.track(ctx, "Version", time.Now())
+
+	return schemaVersion(db.Connection)
+}
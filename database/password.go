@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+**
+** #PASSWORD
+**
+ */
+
+const (
+	// Stored hashes are "pbkdf2-sha256$iterations$salt$hash"
+	passwordScheme     = "pbkdf2-sha256"
+	passwordIterations = 100000
+	passwordSaltSize   = 16
+	passwordKeySize    = 32
+	// Size of secrets made by GetSecret
+	secretSize = 32
+)
+
+// SetPlayerPassword sets password player identifies with, empty password
+// removes it
+func (db *SQLiteDB) SetPlayerPassword(ctx context.Context, name string, password string) (int64, error) {
+	defer db.track(ctx, "SetPlayerPassword", time.Now())
+
+	hash := ""
+	if password != "" {
+		var err error
+		hash, err = hashPassword(password)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	stmt, err := db.Connection.PrepareContext(ctx, "UPDATE player SET password = ? WHERE name = ? AND deleted_at IS NULL")
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, hash, name)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
+
+// CheckPlayerPassword tells if password is player's. Player without
+// password can't be identified as.
+func (db *SQLiteDB) CheckPlayerPassword(ctx context.Context, name string, password string) (bool, error) {
+	defer db.track(ctx, "CheckPlayerPassword", time.Now())
+
+	var hash string
+	err := db.Connection.QueryRowContext(ctx, "SELECT password FROM player WHERE name = ? AND deleted_at IS NULL", name).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if hash == "" || password == "" {
+		return false, nil
+	}
+	return checkPassword(hash, password)
+}
+
+// GetSecret returns random key stored with name, key is made when it's
+// asked first time. Keys signing cookies and links survive restarts this way.
+func (db *SQLiteDB) GetSecret(ctx context.Context, name string) ([]byte, error) {
+	defer db.track(ctx, "GetSecret", time.Now())
+
+	value := make([]byte, secretSize)
+	_, err := rand.Read(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Someone else may make it at the same time, first one is kept
+	_, err = db.Connection.ExecContext(ctx, "INSERT OR IGNORE INTO secret(name, value) VALUES(?, ?)", name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Connection.QueryRowContext(ctx, "SELECT value FROM secret WHERE name = ?", name).Scan(&value)
+	return value, err
+}
+
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeySize)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		passwordScheme,
+		strconv.Itoa(passwordIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+func checkPassword(hash string, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false, fmt.Errorf("unknown password hash")
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, err
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1, nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #SHARE
+**
+ */
+
+const shareLinkColumns = "id, title, game_name, player1, player2, date_from, date_to, expires, created_by, created, revoked_at IS NOT NULL"
+
+// GetShareLinks returns all share links, newest first
+func (db *SQLiteDB) GetShareLinks(ctx context.Context) ([]model.ShareLink, error) {
+	defer db.track(ctx, "GetShareLinks", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx, "SELECT "+shareLinkColumns+" FROM share_link ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]model.ShareLink, 0)
+
+	for rows.Next() {
+		l := model.ShareLink{}
+		err := rows.Scan(&l.ID, &l.Title, &l.GameName, &l.Player1, &l.Player2, &l.From, &l.To,
+			&l.Expires, &l.CreatedBy, &l.Created, &l.Revoked)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// GetShareLink returns share link by ID
+func (db *SQLiteDB) GetShareLink(ctx context.Context, id int) (model.ShareLink, error) {
+	defer db.track(ctx, "GetShareLink", time.Now())
+
+	l := model.ShareLink{}
+	err := db.Connection.QueryRowContext(ctx, "SELECT "+shareLinkColumns+" FROM share_link WHERE id = ?", id).Scan(
+		&l.ID, &l.Title, &l.GameName, &l.Player1, &l.Player2, &l.From, &l.To,
+		&l.Expires, &l.CreatedBy, &l.Created, &l.Revoked,
+	)
+	return l, err
+}
+
+// CreateShareLink adds share link, returns its ID. Expires is RFC3339.
+func (db *SQLiteDB) CreateShareLink(ctx context.Context, l model.ShareLink) (int64, error) {
+	defer db.track(ctx, "CreateShareLink", time.Now())
+
+	expires, err := time.Parse(time.RFC3339, l.Expires)
+	if err != nil {
+		return -1, err
+	}
+
+	stmt, err := db.Connection.PrepareContext(ctx,
+		`INSERT INTO share_link(title, game_name, player1, player2, date_from, date_to, expires, created_by)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+	)
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, l.Title, l.GameName, l.Player1, l.Player2, l.From, l.To,
+		expires.UTC().Format(timestampFormat), l.CreatedBy)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.LastInsertId()
+}
+
+// RevokeShareLink stops share link from working, returns number of links
+// revoked
+func (db *SQLiteDB) RevokeShareLink(ctx context.Context, id int) (int64, error) {
+	defer db.track(ctx, "RevokeShareLink", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "UPDATE share_link SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL")
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
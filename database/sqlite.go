@@ -1,16 +1,31 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"strconv"
+	"time"
 
 	// SQLite driver
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/tuommii/jumbo/logging"
 	"github.com/tuommii/jumbo/model"
 )
 
+// DefaultSlowQuery is when database call is logged as slow
+const DefaultSlowQuery = 100 * time.Millisecond
+
+// Format of times written to TIMESTAMP columns, same as CURRENT_TIMESTAMP
+// so they compare
+const timestampFormat = "2006-01-02 15:04:05"
+
 // SQLiteDB implements Database interface
 type SQLiteDB struct {
 	Connection *sql.DB
+	// Database file name as given to NewSQLiteDB
+	File string
+	// Calls taking longer are logged with caller's logger, zero disables
+	SlowQuery time.Duration
 }
 
 const schema = `
@@ -39,6 +54,119 @@ CREATE TABLE IF NOT EXISTS match(
 		is_tie BOOLEAN NOT NULL,
 		added TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		CONSTRAINT game_PK PRIMARY KEY(id));
+
+CREATE TABLE IF NOT EXISTS match_history(
+		id INTEGER NOT NULL,
+		match_id INTEGER NOT NULL,
+		changed_by TEXT NOT NULL,
+		changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		field TEXT NOT NULL,
+		old_value TEXT NOT NULL,
+		new_value TEXT NOT NULL,
+		CONSTRAINT match_history_PK PRIMARY KEY(id));
+
+CREATE TABLE IF NOT EXISTS audit_log(
+		id INTEGER NOT NULL,
+		created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		actor TEXT NOT NULL,
+		remote_addr TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		entity TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		before TEXT NOT NULL,
+		after TEXT NOT NULL,
+		CONSTRAINT audit_log_PK PRIMARY KEY(id));
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+
+CREATE TABLE IF NOT EXISTS ladder(
+		game_name TEXT NOT NULL,
+		player TEXT NOT NULL,
+		rank INTEGER NOT NULL,
+		CONSTRAINT ladder_PK PRIMARY KEY(game_name, player));
+
+CREATE TABLE IF NOT EXISTS challenge(
+		id INTEGER NOT NULL,
+		game_name TEXT NOT NULL,
+		challenger TEXT NOT NULL,
+		opponent TEXT NOT NULL,
+		status TEXT NOT NULL,
+		match_id INTEGER,
+		created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		deadline TIMESTAMP NOT NULL,
+		CONSTRAINT challenge_PK PRIMARY KEY(id));
+
+CREATE TABLE IF NOT EXISTS alias(
+		alias TEXT NOT NULL COLLATE NOCASE,
+		player_name TEXT NOT NULL,
+		CONSTRAINT alias_PK PRIMARY KEY(alias));
+
+CREATE TABLE IF NOT EXISTS webhook(
+		id INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT NOT NULL,
+		created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		CONSTRAINT webhook_PK PRIMARY KEY(id));
+
+CREATE TABLE IF NOT EXISTS webhook_delivery(
+		id INTEGER NOT NULL,
+		webhook_id INTEGER NOT NULL,
+		event TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_status INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		CONSTRAINT webhook_delivery_PK PRIMARY KEY(id));
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_due ON webhook_delivery(status, next_attempt);
+
+CREATE TABLE IF NOT EXISTS chat_user(
+		user_id TEXT NOT NULL,
+		player_name TEXT NOT NULL,
+		CONSTRAINT chat_user_PK PRIMARY KEY(user_id));
+
+CREATE TABLE IF NOT EXISTS scheduled_event(
+		id INTEGER NOT NULL,
+		game_name TEXT NOT NULL,
+		title TEXT NOT NULL,
+		starts TIMESTAMP NOT NULL,
+		ends TIMESTAMP NOT NULL,
+		location TEXT NOT NULL,
+		created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		CONSTRAINT scheduled_event_PK PRIMARY KEY(id));
+
+CREATE TABLE IF NOT EXISTS scheduled_event_player(
+		event_id INTEGER NOT NULL,
+		player TEXT NOT NULL,
+		CONSTRAINT scheduled_event_player_PK PRIMARY KEY(event_id, player));
+
+CREATE TABLE IF NOT EXISTS secret(
+		name TEXT NOT NULL,
+		value BLOB NOT NULL,
+		CONSTRAINT secret_PK PRIMARY KEY(name));
+
+CREATE TABLE IF NOT EXISTS share_link(
+		id INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		game_name TEXT NOT NULL,
+		player1 TEXT NOT NULL,
+		player2 TEXT NOT NULL,
+		date_from TEXT NOT NULL,
+		date_to TEXT NOT NULL,
+		expires TIMESTAMP NOT NULL,
+		created_by TEXT NOT NULL,
+		created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMP,
+		CONSTRAINT share_link_PK PRIMARY KEY(id));
 `
 
 // NewSQLiteDB returns connection to SQLite database
@@ -58,7 +186,23 @@ func NewSQLiteDB(name string) (*SQLiteDB, error) {
 		return nil, err
 	}
 
-	return &SQLiteDB{Connection: db}, nil
+	err = migrate(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteDB{Connection: db, File: name, SlowQuery: DefaultSlowQuery}, nil
+}
+
+// track records duration of call that started at start and logs it if it
+// was slow, call with defer
+func (db *SQLiteDB) track(ctx context.Context, method string, start time.Time) {
+	elapsed := time.Since(start)
+	queryDuration.Observe(elapsed.Seconds(), method)
+
+	if db.SlowQuery > 0 && elapsed >= db.SlowQuery {
+		logging.FromContext(ctx).Warn("slow query", "method", method, "duration", elapsed)
+	}
 }
 
 /*
@@ -68,8 +212,10 @@ func NewSQLiteDB(name string) (*SQLiteDB, error) {
  */
 
 // GetPlayers returns all players
-func (db *SQLiteDB) GetPlayers() ([]model.Player, error) {
-	rows, err := db.Connection.Query("SELECT id, name FROM player")
+func (db *SQLiteDB) GetPlayers(ctx context.Context) ([]model.Player, error) {
+	defer db.track(ctx, "GetPlayers", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx, "SELECT id, name FROM player WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -91,14 +237,21 @@ func (db *SQLiteDB) GetPlayers() ([]model.Player, error) {
 }
 
 // CreatePlayer creates new player
-func (db *SQLiteDB) CreatePlayer(name string) (int64, error) {
-	stmt, err := db.Connection.Prepare("INSERT INTO player(name) VALUES(?)")
+func (db *SQLiteDB) CreatePlayer(ctx context.Context, name string) (int64, error) {
+	defer db.track(ctx, "CreatePlayer", time.Now())
+
+	err := checkTrash(ctx, db.Connection, model.KindPlayer, name)
+	if err != nil {
+		return -1, err
+	}
+
+	stmt, err := db.Connection.PrepareContext(ctx, "INSERT INTO player(name) VALUES(?)")
 	if err != nil {
 		return -1, err
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(name)
+	res, err := stmt.ExecContext(ctx, name)
 	if err != nil {
 		return -1, err
 	}
@@ -106,15 +259,17 @@ func (db *SQLiteDB) CreatePlayer(name string) (int64, error) {
 	return res.LastInsertId()
 }
 
-// DeletePlayer deletes player
-func (db *SQLiteDB) DeletePlayer(name string) (int64, error) {
-	stmt, err := db.Connection.Prepare("DELETE FROM player WHERE name = ?")
+// DeletePlayer moves player to trash
+func (db *SQLiteDB) DeletePlayer(ctx context.Context, name string) (int64, error) {
+	defer db.track(ctx, "DeletePlayer", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "UPDATE player SET deleted_at = CURRENT_TIMESTAMP WHERE name = ? AND deleted_at IS NULL")
 	if err != nil {
 		return -1, err
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(name)
+	res, err := stmt.ExecContext(ctx, name)
 	if err != nil {
 		return -1, err
 	}
@@ -122,6 +277,56 @@ func (db *SQLiteDB) DeletePlayer(name string) (int64, error) {
 	return res.RowsAffected()
 }
 
+// renamedColumns hold player names outside player table
+var renamedColumns = []string{
+	"UPDATE match SET winner = ? WHERE winner = ?",
+	"UPDATE match SET loser = ? WHERE loser = ?",
+	"UPDATE match SET reported_by = ? WHERE reported_by = ?",
+	"UPDATE ladder SET player = ? WHERE player = ?",
+	"UPDATE challenge SET challenger = ? WHERE challenger = ?",
+	"UPDATE challenge SET opponent = ? WHERE opponent = ?",
+	"UPDATE alias SET player_name = ? WHERE player_name = ?",
+	"UPDATE chat_user SET player_name = ? WHERE player_name = ?",
+	"UPDATE scheduled_event_player SET player = ? WHERE player = ?",
+	"UPDATE share_link SET player1 = ? WHERE player1 = ?",
+	"UPDATE share_link SET player2 = ? WHERE player2 = ?",
+}
+
+// RenamePlayer renames player everywhere player's name is used
+func (db *SQLiteDB) RenamePlayer(ctx context.Context, name string, newName string) (int64, error) {
+	defer db.track(ctx, "RenamePlayer", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	err = checkTrash(ctx, tx, model.KindPlayer, newName)
+	if err != nil {
+		return -1, err
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE player SET name = ? WHERE name = ? AND deleted_at IS NULL", newName, name)
+	if err != nil {
+		return -1, err
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil || num == 0 {
+		return num, err
+	}
+
+	for _, query := range renamedColumns {
+		_, err = tx.ExecContext(ctx, query, newName, name)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return num, tx.Commit()
+}
+
 /*
 **
 ** #GAME
@@ -129,8 +334,10 @@ func (db *SQLiteDB) DeletePlayer(name string) (int64, error) {
  */
 
 // GetGames returns all games
-func (db *SQLiteDB) GetGames() ([]model.Game, error) {
-	rows, err := db.Connection.Query("SELECT id, name FROM game")
+func (db *SQLiteDB) GetGames(ctx context.Context) ([]model.Game, error) {
+	defer db.track(ctx, "GetGames", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx, "SELECT id, name, confirm FROM game WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +347,7 @@ func (db *SQLiteDB) GetGames() ([]model.Game, error) {
 
 	for rows.Next() {
 		game := model.Game{}
-		err := rows.Scan(&game.ID, &game.Name)
+		err := rows.Scan(&game.ID, &game.Name, &game.Confirm)
 		if err != nil {
 			return nil, err
 		}
@@ -152,14 +359,21 @@ func (db *SQLiteDB) GetGames() ([]model.Game, error) {
 }
 
 // CreateGame creates new game
-func (db *SQLiteDB) CreateGame(name string) (int64, error) {
-	stmt, err := db.Connection.Prepare("INSERT INTO game(name) VALUES(?)")
+func (db *SQLiteDB) CreateGame(ctx context.Context, name string) (int64, error) {
+	defer db.track(ctx, "CreateGame", time.Now())
+
+	err := checkTrash(ctx, db.Connection, model.KindGame, name)
+	if err != nil {
+		return -1, err
+	}
+
+	stmt, err := db.Connection.PrepareContext(ctx, "INSERT INTO game(name) VALUES(?)")
 	if err != nil {
 		return -1, err
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(name)
+	res, err := stmt.ExecContext(ctx, name)
 	if err != nil {
 		return -1, err
 	}
@@ -167,15 +381,17 @@ func (db *SQLiteDB) CreateGame(name string) (int64, error) {
 	return res.LastInsertId()
 }
 
-// DeleteGame deletes player
-func (db *SQLiteDB) DeleteGame(name string) (int64, error) {
-	stmt, err := db.Connection.Prepare("DELETE FROM game WHERE name = ?")
+// DeleteGame moves game to trash
+func (db *SQLiteDB) DeleteGame(ctx context.Context, name string) (int64, error) {
+	defer db.track(ctx, "DeleteGame", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "UPDATE game SET deleted_at = CURRENT_TIMESTAMP WHERE name = ? AND deleted_at IS NULL")
 	if err != nil {
 		return -1, err
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(name)
+	res, err := stmt.ExecContext(ctx, name)
 	if err != nil {
 		return -1, err
 	}
@@ -189,31 +405,101 @@ func (db *SQLiteDB) DeleteGame(name string) (int64, error) {
 **
  */
 
-// CreateMatch creates new match
-func (db *SQLiteDB) CreateMatch(match model.Match) (int64, error) {
-	stmt, err := db.Connection.Prepare("INSERT INTO match(game_name, winner, loser, comment, is_tie) VALUES(?,?,?,?,?)")
+// CreateMatch creates new match. Match is pending if game requires
+// confirmation, otherwise open ladder challenge between players is resolved
+// in same transaction.
+func (db *SQLiteDB) CreateMatch(ctx context.Context, match model.Match) (int64, error) {
+	defer db.track(ctx, "CreateMatch", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	var confirm bool
+	err = tx.QueryRowContext(ctx, "SELECT confirm FROM game WHERE name = ? AND deleted_at IS NULL", match.GameName).Scan(&confirm)
+	if err != nil && err != sql.ErrNoRows {
+		return -1, err
+	}
+
+	match.Status = model.MatchConfirmed
+	if confirm {
+		if match.ReportedBy == "" {
+			return -1, ErrNotIdentified
+		}
+		match.Status = model.MatchPending
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO match(game_name, winner, loser, comment, is_tie, status, reported_by, winner_score, loser_score)
+		VALUES(?,?,?,?,?,?,?,?,?)`,
+	)
 	if err != nil {
 		return -1, err
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(match.GameName, match.Winner, match.Loser, match.Comment, match.IsTie)
+	res, err := stmt.ExecContext(ctx,
+		match.GameName, match.Winner, match.Loser, match.Comment, match.IsTie, match.Status, match.ReportedBy,
+		match.WinnerScore, match.LoserScore,
+	)
 	if err != nil {
 		return -1, err
 	}
 
-	return res.LastInsertId()
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+
+	if match.Status == model.MatchConfirmed {
+		err = resolveChallenge(ctx, tx, match, id)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return id, tx.Commit()
 }
 
 // GetMatches returns all matches
-func (db *SQLiteDB) GetMatches(f model.Filter) ([]model.Match, error) {
-	rows, err := db.Connection.Query(f.GetQuery())
+func (db *SQLiteDB) GetMatches(ctx context.Context, f model.Filter) ([]model.Match, error) {
+	defer db.track(ctx, "GetMatches", time.Now())
+
+	return db.getMatches(ctx, f)
+}
+
+// getMatches is GetMatches without tracking
+func (db *SQLiteDB) getMatches(ctx context.Context, f model.Filter) ([]model.Match, error) {
+	matches := make([]model.Match, 0)
+
+	err := db.eachMatch(ctx, f, func(match model.Match) error {
+		matches = append(matches, match)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return matches, nil
+}
 
-	matches := make([]model.Match, 0)
+// EachMatch calls fn for every match straight from cursor, stops on first
+// error
+func (db *SQLiteDB) EachMatch(ctx context.Context, f model.Filter, fn func(model.Match) error) error {
+	defer db.track(ctx, "EachMatch", time.Now())
+
+	return db.eachMatch(ctx, f, fn)
+}
+
+// eachMatch is EachMatch without tracking, so callers track only themselves
+func (db *SQLiteDB) eachMatch(ctx context.Context, f model.Filter, fn func(model.Match) error) error {
+	query, args := f.GetQuery()
+	rows, err := db.Connection.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
 	for rows.Next() {
 		match := model.Match{}
@@ -225,24 +511,206 @@ func (db *SQLiteDB) GetMatches(f model.Filter) ([]model.Match, error) {
 			&match.Loser,
 			&match.Comment,
 			&match.Added,
+			&match.WinnerScore,
+			&match.LoserScore,
 		)
+		if err != nil {
+			return err
+		}
+
+		err = fn(match)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportMatches saves matches with their original added times, creating
+// missing players and games first. Everything is saved or nothing is.
+func (db *SQLiteDB) ImportMatches(ctx context.Context, imp model.Import) (int64, error) {
+	defer db.track(ctx, "ImportMatches", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	for _, name := range imp.Players {
+		err = checkTrash(ctx, tx, model.KindPlayer, name)
+		if err != nil {
+			return -1, err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO player(name) VALUES(?)", name)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	for _, name := range imp.Games {
+		err = checkTrash(ctx, tx, model.KindGame, name)
+		if err != nil {
+			return -1, err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO game(name) VALUES(?)", name)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO match(game_name, winner, loser, comment, is_tie, added, status, reported_by, winner_score, loser_score)
+		VALUES(?,?,?,?,?,?,?,?,?,?)`,
+	)
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	for _, m := range imp.Matches {
+		_, err = stmt.ExecContext(ctx,
+			m.GameName, m.Winner, m.Loser, m.Comment, m.IsTie, m.Added,
+			model.MatchConfirmed, m.ReportedBy, m.WinnerScore, m.LoserScore,
+		)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return int64(len(imp.Matches)), tx.Commit()
+}
+
+// GetMatch returns match by id, whatever its status is
+func (db *SQLiteDB) GetMatch(ctx context.Context, id int) (model.Match, error) {
+	defer db.track(ctx, "GetMatch", time.Now())
+
+	match := model.Match{}
+	err := db.Connection.QueryRowContext(ctx,
+		`SELECT id, game_name, is_tie, winner, loser, comment, added, status, reported_by, winner_score, loser_score
+		FROM match WHERE id = ? AND deleted_at IS NULL`, id,
+	).Scan(
+		&match.ID,
+		&match.GameName,
+		&match.IsTie,
+		&match.Winner,
+		&match.Loser,
+		&match.Comment,
+		&match.Added,
+		&match.Status,
+		&match.ReportedBy,
+		&match.WinnerScore,
+		&match.LoserScore,
+	)
+	return match, err
+}
+
+// UpdateMatch changes match's game, players, tie flag and comment. Every
+// changed field is saved to match_history.
+func (db *SQLiteDB) UpdateMatch(ctx context.Context, match model.Match, changedBy string) (int64, error) {
+	defer db.track(ctx, "UpdateMatch", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	old := model.Match{}
+	err = tx.QueryRowContext(ctx, "SELECT game_name, winner, loser, is_tie, comment FROM match WHERE id = ? AND deleted_at IS NULL", match.ID).Scan(
+		&old.GameName, &old.Winner, &old.Loser, &old.IsTie, &old.Comment,
+	)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	changes := []struct {
+		field    string
+		oldValue string
+		newValue string
+	}{
+		{"game_name", old.GameName, match.GameName},
+		{"winner", old.Winner, match.Winner},
+		{"loser", old.Loser, match.Loser},
+		{"is_tie", strconv.FormatBool(old.IsTie), strconv.FormatBool(match.IsTie)},
+		{"comment", old.Comment, match.Comment},
+	}
+
+	var num int64
+	for _, c := range changes {
+		if c.oldValue == c.newValue {
+			continue
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO match_history(match_id, changed_by, field, old_value, new_value) VALUES(?,?,?,?,?)",
+			match.ID, changedBy, c.field, c.oldValue, c.newValue,
+		)
+		if err != nil {
+			return -1, err
+		}
+		num++
+	}
+
+	if num == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE match SET game_name = ?, winner = ?, loser = ?, is_tie = ?, comment = ? WHERE id = ?",
+		match.GameName, match.Winner, match.Loser, match.IsTie, match.Comment, match.ID,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	err = reresolveChallenge(ctx, tx, old, match)
+	if err != nil {
+		return -1, err
+	}
+
+	return num, tx.Commit()
+}
+
+// GetMatchHistory returns changes made to match, oldest first
+func (db *SQLiteDB) GetMatchHistory(ctx context.Context, id int) ([]model.MatchChange, error) {
+	defer db.track(ctx, "GetMatchHistory", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx,
+		"SELECT id, match_id, changed_by, changed_at, field, old_value, new_value FROM match_history WHERE match_id = ? ORDER BY id",
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]model.MatchChange, 0)
+
+	for rows.Next() {
+		c := model.MatchChange{}
+		err := rows.Scan(&c.ID, &c.MatchID, &c.ChangedBy, &c.ChangedAt, &c.Field, &c.OldValue, &c.NewValue)
 		if err != nil {
 			return nil, err
 		}
-		matches = append(matches, match)
+		history = append(history, c)
 	}
-	return matches, nil
+	return history, rows.Err()
 }
 
-// DeleteMatch deletes match
-func (db *SQLiteDB) DeleteMatch(id int) (int64, error) {
-	stmt, err := db.Connection.Prepare("DELETE FROM match WHERE id = ?")
+// DeleteMatch moves match to trash
+func (db *SQLiteDB) DeleteMatch(ctx context.Context, id int) (int64, error) {
+	defer db.track(ctx, "DeleteMatch", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "UPDATE match SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL")
 	if err != nil {
 		return -1, err
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(id)
+	res, err := stmt.ExecContext(ctx, id)
 	if err != nil {
 		return -1, err
 	}
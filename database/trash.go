@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #TRASH
+**
+ */
+
+// ErrUnknownKind is returned for kinds that can't be deleted
+var ErrUnknownKind = errors.New("unknown kind")
+
+// ErrInTrash is returned when name is taken by player or game in trash
+var ErrInTrash = errors.New("is in trash, restore or purge it first")
+
+// Table for each deletable kind, kinds are never used in queries directly
+var kindTables = map[string]string{
+	model.KindPlayer: "player",
+	model.KindGame:   "game",
+	model.KindMatch:  "match",
+}
+
+// rowQuerier is database or transaction
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// checkTrash returns ErrInTrash if player or game in trash has name, names
+// stay unique in trash too
+func checkTrash(ctx context.Context, q rowQuerier, kind string, name string) error {
+	var count int
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+kindTables[kind]+" WHERE name = ? AND deleted_at IS NOT NULL", name).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%s %s %w", kind, name, ErrInTrash)
+	}
+	return nil
+}
+
+// GetDeleted returns everything in trash, latest first
+func (db *SQLiteDB) GetDeleted(ctx context.Context) ([]model.Deleted, error) {
+	defer db.track(ctx, "GetDeleted", time.Now())
+
+	rows, err := db.Connection.QueryContext(ctx, `
+		SELECT ?, id, name, deleted_at FROM player WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT ?, id, name, deleted_at FROM game WHERE deleted_at IS NOT NULL
+		UNION ALL
+		SELECT ?, id, game_name || ': ' || winner || ' - ' || loser, deleted_at FROM match WHERE deleted_at IS NOT NULL
+		ORDER BY 4 DESC`,
+		model.KindPlayer, model.KindGame, model.KindMatch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := make([]model.Deleted, 0)
+
+	for rows.Next() {
+		d := model.Deleted{}
+		err := rows.Scan(&d.Kind, &d.ID, &d.Name, &d.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, d)
+	}
+	return deleted, rows.Err()
+}
+
+// Restore takes row back from trash
+func (db *SQLiteDB) Restore(ctx context.Context, kind string, id int) (int64, error) {
+	defer db.track(ctx, "Restore", time.Now())
+
+	table, ok := kindTables[kind]
+	if !ok {
+		return -1, ErrUnknownKind
+	}
+
+	stmt, err := db.Connection.PrepareContext(ctx, "UPDATE "+table+" SET deleted_at = NULL WHERE id = ?")
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Purge deletes row in trash for good. Match's history goes with it.
+func (db *SQLiteDB) Purge(ctx context.Context, kind string, id int) (int64, error) {
+	defer db.track(ctx, "Purge", time.Now())
+
+	table, ok := kindTables[kind]
+	if !ok {
+		return -1, ErrUnknownKind
+	}
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return -1, err
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+
+	if kind == model.KindMatch && num > 0 {
+		_, err = tx.ExecContext(ctx, "DELETE FROM match_history WHERE match_id = ?", id)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	return num, tx.Commit()
+}
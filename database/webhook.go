@@ -0,0 +1,237 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+/*
+**
+** #WEBHOOK
+**
+ */
+
+// GetWebhooks returns all webhooks, oldest first
+func (db *SQLiteDB) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	defer db.track(ctx, "GetWebhooks", time.Now())
+
+	return db.getWebhooks(ctx)
+}
+
+// getWebhooks is GetWebhooks without tracking
+func (db *SQLiteDB) getWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	rows, err := db.Connection.QueryContext(ctx, "SELECT id, url, secret, events, created FROM webhook ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+
+	for rows.Next() {
+		w := model.Webhook{}
+		var events string
+		err := rows.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.Created)
+		if err != nil {
+			return nil, err
+		}
+		if events != "" {
+			w.Events = strings.Split(events, ",")
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// CreateWebhook adds webhook, returns its ID
+func (db *SQLiteDB) CreateWebhook(ctx context.Context, w model.Webhook) (int64, error) {
+	defer db.track(ctx, "CreateWebhook", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx, "INSERT INTO webhook(url, secret, events) VALUES(?, ?, ?)")
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, w.URL, w.Secret, w.EventList())
+	if err != nil {
+		return -1, err
+	}
+
+	return res.LastInsertId()
+}
+
+// DeleteWebhook deletes webhook, its pending deliveries fail. Delivery log
+// is kept.
+func (db *SQLiteDB) DeleteWebhook(ctx context.Context, id int) (int64, error) {
+	defer db.track(ctx, "DeleteWebhook", time.Now())
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM webhook WHERE id = ?", id)
+	if err != nil {
+		return -1, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE webhook_delivery SET status = ?, last_error = 'webhook deleted' WHERE webhook_id = ? AND status = ?",
+		model.DeliveryFailed, id, model.DeliveryPending,
+	)
+	if err != nil {
+		return -1, err
+	}
+
+	num, err := res.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+	return num, tx.Commit()
+}
+
+// QueueEvent queues payload to every webhook that wants event, returns
+// number of deliveries queued
+func (db *SQLiteDB) QueueEvent(ctx context.Context, event string, payload string) (int64, error) {
+	defer db.track(ctx, "QueueEvent", time.Now())
+
+	webhooks, err := db.getWebhooks(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	tx, err := db.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	var num int64
+	for _, w := range webhooks {
+		if !w.Wants(event) {
+			continue
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO webhook_delivery(webhook_id, event, payload, status) VALUES(?, ?, ?, ?)",
+			w.ID, event, payload, model.DeliveryPending,
+		)
+		if err != nil {
+			return -1, err
+		}
+		num++
+	}
+
+	return num, tx.Commit()
+}
+
+// QueueDelivery queues payload to one webhook whatever its events are
+func (db *SQLiteDB) QueueDelivery(ctx context.Context, webhookID int, event string, payload string) (int64, error) {
+	defer db.track(ctx, "QueueDelivery", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx,
+		"INSERT INTO webhook_delivery(webhook_id, event, payload, status) SELECT id, ?, ?, ? FROM webhook WHERE id = ?",
+	)
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, event, payload, model.DeliveryPending, webhookID)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
+
+const deliveryColumns = `d.id, d.webhook_id, IFNULL(w.url, ''), IFNULL(w.secret, ''), d.event, d.payload,
+	d.status, d.attempts, d.next_attempt, d.last_status, d.last_error, d.created
+	FROM webhook_delivery d LEFT JOIN webhook w ON w.id = d.webhook_id`
+
+// GetDueDeliveries returns pending deliveries whose next attempt is due,
+// oldest first
+func (db *SQLiteDB) GetDueDeliveries(ctx context.Context, limit int) ([]model.Delivery, error) {
+	defer db.track(ctx, "GetDueDeliveries", time.Now())
+
+	return db.queryDeliveries(ctx,
+		"SELECT "+deliveryColumns+" WHERE d.status = ? AND d.next_attempt <= CURRENT_TIMESTAMP ORDER BY d.id LIMIT ?",
+		model.DeliveryPending, limit,
+	)
+}
+
+// GetDeliveries returns delivery log, latest first
+func (db *SQLiteDB) GetDeliveries(ctx context.Context, limit int) ([]model.Delivery, error) {
+	defer db.track(ctx, "GetDeliveries", time.Now())
+
+	return db.queryDeliveries(ctx, "SELECT "+deliveryColumns+" ORDER BY d.id DESC LIMIT ?", limit)
+}
+
+func (db *SQLiteDB) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]model.Delivery, error) {
+	rows, err := db.Connection.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.Delivery, 0)
+
+	for rows.Next() {
+		d := model.Delivery{}
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.URL, &d.Secret, &d.Event, &d.Payload,
+			&d.Status, &d.Attempts, &d.NextAttempt, &d.LastStatus, &d.LastError, &d.Created)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// UpdateDelivery saves result of delivery attempt, next attempt is retryIn
+// from now
+func (db *SQLiteDB) UpdateDelivery(ctx context.Context, d model.Delivery, retryIn time.Duration) (int64, error) {
+	defer db.track(ctx, "UpdateDelivery", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx,
+		`UPDATE webhook_delivery SET status = ?, attempts = ?, next_attempt = ?, last_status = ?, last_error = ?
+		WHERE id = ?`,
+	)
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	next := time.Now().UTC().Add(retryIn).Format(timestampFormat)
+	res, err := stmt.ExecContext(ctx, d.Status, d.Attempts, next, d.LastStatus, d.LastError, d.ID)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
+
+// RetryDelivery queues failed delivery again, attempts start from zero
+func (db *SQLiteDB) RetryDelivery(ctx context.Context, id int) (int64, error) {
+	defer db.track(ctx, "RetryDelivery", time.Now())
+
+	stmt, err := db.Connection.PrepareContext(ctx,
+		`UPDATE webhook_delivery SET status = ?, attempts = 0, next_attempt = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ? AND webhook_id IN (SELECT id FROM webhook)`,
+	)
+	if err != nil {
+		return -1, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, model.DeliveryPending, id, model.DeliveryFailed)
+	if err != nil {
+		return -1, err
+	}
+
+	return res.RowsAffected()
+}
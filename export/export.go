@@ -0,0 +1,201 @@
+// Package export writes matches and stats as CSV, JSON or NDJSON, and
+// matches also as PGN
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tuommii/jumbo/model"
+	"github.com/tuommii/jumbo/pgn"
+)
+
+// Formats
+const (
+	CSV    = "csv"
+	JSON   = "json"
+	NDJSON = "ndjson"
+	PGN    = "pgn"
+)
+
+// ErrFormat is returned for unknown format
+var ErrFormat = errors.New("unknown export format, use csv, json, ndjson or pgn")
+
+// ContentType returns MIME type for format
+func ContentType(format string) string {
+	switch format {
+	case JSON:
+		return "application/json"
+	case NDJSON:
+		return "application/x-ndjson"
+	case PGN:
+		return "application/x-chess-pgn"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// MatchWriter writes matches one by one, Close must be called after last
+type MatchWriter interface {
+	Write(m model.Match) error
+	Close() error
+}
+
+// NewMatchWriter returns writer for format
+func NewMatchWriter(w io.Writer, format string) (MatchWriter, error) {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write([]string{"id", "added", "game", "winner", "loser", "tie", "comment", "winner_score", "loser_score"})
+		return &csvMatchWriter{cw}, err
+	case JSON:
+		return &jsonMatchWriter{w: w, enc: json.NewEncoder(w)}, nil
+	case NDJSON:
+		return &ndjsonMatchWriter{json.NewEncoder(w)}, nil
+	case PGN:
+		return &pgnMatchWriter{w}, nil
+	}
+	return nil, ErrFormat
+}
+
+// WriteStats writes stats in format
+func WriteStats(w io.Writer, format string, stats model.SortedStats) error {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"name", "games", "wins", "ties", "losses", "win_percentage", "highest_win_streak", "current_win_streak"})
+		for _, s := range stats {
+			cw.Write([]string{
+				s.Name,
+				strconv.Itoa(s.Games),
+				strconv.Itoa(s.Wins),
+				strconv.Itoa(s.Ties),
+				strconv.Itoa(s.Losses),
+				strconv.FormatFloat(s.WinPercentage, 'f', 4, 64),
+				strconv.Itoa(s.HighestWinStreak),
+				strconv.Itoa(s.CurrentWinStreak),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case JSON:
+		return json.NewEncoder(w).Encode(stats)
+	case NDJSON:
+		enc := json.NewEncoder(w)
+		for _, s := range stats {
+			err := enc.Encode(s)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrFormat
+}
+
+type csvMatchWriter struct {
+	cw *csv.Writer
+}
+
+func (c *csvMatchWriter) Write(m model.Match) error {
+	return c.cw.Write([]string{
+		strconv.Itoa(m.ID),
+		m.Added,
+		m.GameName,
+		m.Winner,
+		m.Loser,
+		strconv.FormatBool(m.IsTie),
+		m.Comment,
+		formatScore(m.WinnerScore),
+		formatScore(m.LoserScore),
+	})
+}
+
+// formatScore returns empty string for missing score
+func formatScore(score *int) string {
+	if score == nil {
+		return ""
+	}
+	return strconv.Itoa(*score)
+}
+
+func (c *csvMatchWriter) Close() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// jsonMatchWriter writes one array without holding matches in memory
+type jsonMatchWriter struct {
+	w     io.Writer
+	enc   *json.Encoder
+	count int
+}
+
+func (j *jsonMatchWriter) Write(m model.Match) error {
+	sep := ","
+	if j.count == 0 {
+		sep = "["
+	}
+	j.count++
+
+	_, err := io.WriteString(j.w, sep)
+	if err != nil {
+		return err
+	}
+	return j.enc.Encode(m)
+}
+
+func (j *jsonMatchWriter) Close() error {
+	end := "]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type ndjsonMatchWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonMatchWriter) Write(m model.Match) error {
+	return n.enc.Encode(m)
+}
+
+func (n *ndjsonMatchWriter) Close() error {
+	return nil
+}
+
+// pgnMatchWriter writes header-only games. Colors aren't recorded, so
+// winner is always White.
+type pgnMatchWriter struct {
+	w io.Writer
+}
+
+func (p *pgnMatchWriter) Write(m model.Match) error {
+	result := pgn.WhiteWins
+	if m.IsTie {
+		result = pgn.Draw
+	}
+
+	date := strings.Replace(strings.Split(m.Added, "T")[0], "-", ".", -1)
+
+	return pgn.Write(p.w, pgn.Game{Tags: map[string]string{
+		"Event":   "Jumbo " + m.GameName,
+		"Site":    "?",
+		"Date":    date,
+		"Round":   "-",
+		"White":   m.Winner,
+		"Black":   m.Loser,
+		"Result":  result,
+		"JumboID": strconv.Itoa(m.ID),
+	}})
+}
+
+func (p *pgnMatchWriter) Close() error {
+	return nil
+}
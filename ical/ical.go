@@ -0,0 +1,98 @@
+// Package ical writes iCalendar (RFC 5545) feeds
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType of iCalendar
+const ContentType = "text/calendar; charset=utf-8"
+
+// Calendar is feed of events
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Event is VEVENT. UID must not change, calendar apps update event by it.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Created     time.Time
+	Summary     string
+	Location    string
+	Description string
+	URL         string
+}
+
+const utcFormat = "20060102T150405Z"
+
+// Write writes calendar with CRLF line endings and long lines folded
+func Write(w io.Writer, c Calendar) error {
+	bw := bufio.NewWriter(w)
+	stamp := time.Now()
+
+	line(bw, "BEGIN", "VCALENDAR")
+	line(bw, "VERSION", "2.0")
+	line(bw, "PRODID", "-//Jumbo//Jumbo//EN")
+	line(bw, "CALSCALE", "GREGORIAN")
+	line(bw, "METHOD", "PUBLISH")
+	line(bw, "X-WR-CALNAME", escape(c.Name))
+
+	for _, e := range c.Events {
+		line(bw, "BEGIN", "VEVENT")
+		line(bw, "UID", e.UID)
+		line(bw, "DTSTAMP", stamp.UTC().Format(utcFormat))
+		if !e.Created.IsZero() {
+			line(bw, "CREATED", e.Created.UTC().Format(utcFormat))
+		}
+		line(bw, "DTSTART", e.Start.UTC().Format(utcFormat))
+		line(bw, "DTEND", e.End.UTC().Format(utcFormat))
+		line(bw, "SUMMARY", escape(e.Summary))
+		if e.Location != "" {
+			line(bw, "LOCATION", escape(e.Location))
+		}
+		if e.Description != "" {
+			line(bw, "DESCRIPTION", escape(e.Description))
+		}
+		if e.URL != "" {
+			line(bw, "URL", e.URL)
+		}
+		line(bw, "END", "VEVENT")
+	}
+
+	line(bw, "END", "VCALENDAR")
+	return bw.Flush()
+}
+
+// line writes content line folded to 75 octets, without splitting UTF-8
+// characters
+func line(w *bufio.Writer, name string, value string) {
+	s := name + ":" + value
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		// Back up to start of character
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// Leading space of continuation counts
+		limit = 74
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escape escapes TEXT value
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,321 @@
+// Package importer reads historical matches from CSV and checks them
+// against existing players, games and matches before they are saved
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// Columns, header row decides order. Scores are optional.
+const (
+	colDate        = "date"
+	colGame        = "game"
+	colWinner      = "winner"
+	colLoser       = "loser"
+	colTie         = "tie"
+	colComment     = "comment"
+	colWinnerScore = "winner_score"
+	colLoserScore  = "loser_score"
+)
+
+// TimeFormat is how Added is stored, same as SQLite CURRENT_TIMESTAMP
+const TimeFormat = "2006-01-02 15:04:05"
+
+// Accepted date formats, dates without time are midnight UTC
+var dateFormats = []string{
+	TimeFormat,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Row is one match read from file
+type Row struct {
+	// Line in file, header is line 1
+	Line      int
+	Match     model.Match
+	Errors    []string
+	Duplicate bool
+}
+
+// Valid tells if row can be imported
+func (r Row) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Report is result of dry run
+type Report struct {
+	Rows           []Row
+	MissingPlayers []string
+	MissingGames   []string
+	Invalid        int
+	Duplicates     int
+}
+
+// CanImport tells if there are no errors. Duplicates are skipped.
+func (rep *Report) CanImport() bool {
+	return rep.Invalid == 0
+}
+
+// Import returns what to save, missing players and games are only there
+// when report was checked with createMissing
+func (rep *Report) Import() model.Import {
+	imp := model.Import{
+		Players: rep.MissingPlayers,
+		Games:   rep.MissingGames,
+		Matches: make([]model.Match, 0, len(rep.Rows)),
+	}
+
+	for _, row := range rep.Rows {
+		if row.Valid() && !row.Duplicate {
+			imp.Matches = append(imp.Matches, row.Match)
+		}
+	}
+	return imp
+}
+
+// ReadCSV reads rows from CSV with header. Rows that can't be parsed get
+// errors, only broken CSV itself fails.
+func ReadCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{colDate, colGame, colWinner, colLoser} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("column %q is missing", required)
+		}
+	}
+
+	rows := make([]Row, 0)
+	line := 1
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line++
+
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		rows = append(rows, parseRow(line, field))
+	}
+
+	return rows, nil
+}
+
+func parseRow(line int, field func(string) string) Row {
+	m := model.Match{
+		GameName: field(colGame),
+		Winner:   field(colWinner),
+		Loser:    field(colLoser),
+		Comment:  field(colComment),
+	}
+	errs := make([]string, 0)
+
+	added, err := ParseDate(field(colDate))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	m.Added = added
+
+	m.IsTie, err = parseBool(field(colTie))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	m.WinnerScore, err = parseScore(field(colWinnerScore))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	m.LoserScore, err = parseScore(field(colLoserScore))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	return NewRow(line, m, errs...)
+}
+
+// NewRow returns row for match read from any source, errs are what source
+// already found wrong
+func NewRow(line int, m model.Match, errs ...string) Row {
+	row := Row{Line: line, Match: m, Errors: errs}
+	m = row.Match
+
+	if m.GameName == "" {
+		row.Errors = append(row.Errors, "game is empty")
+	}
+	if m.Winner == "" || m.Loser == "" {
+		row.Errors = append(row.Errors, "winner and loser required")
+	} else if strings.EqualFold(m.Winner, m.Loser) {
+		row.Errors = append(row.Errors, "winner and loser cant be same player")
+	}
+
+	// SQL might cry for empty strings
+	if m.Comment == "" {
+		row.Match.Comment = "EMPTY"
+	}
+	row.Match.Status = model.MatchConfirmed
+
+	return row
+}
+
+// Check validates rows against existing players and games and marks rows
+// that are already saved or appear twice in file. With createMissing
+// unknown players and games are reported as missing instead of errors.
+// Names of players and games in trash are errors, they can't be created.
+func Check(rows []Row, players []model.Player, games []model.Game, trashed []model.Deleted, existing []model.Match, createMissing bool) *Report {
+	rep := &Report{Rows: rows}
+
+	inTrash := make(map[[2]string]bool)
+	for _, d := range trashed {
+		inTrash[[2]string{d.Kind, d.Name}] = true
+	}
+
+	knownPlayers := make(map[string]bool)
+	for _, p := range players {
+		knownPlayers[p.Name] = true
+	}
+	knownGames := make(map[string]bool)
+	for _, g := range games {
+		knownGames[g.Name] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range existing {
+		seen[matchKey(m)] = true
+	}
+
+	for i := range rep.Rows {
+		row := &rep.Rows[i]
+		m := row.Match
+
+		if m.GameName != "" && !knownGames[m.GameName] {
+			if inTrash[[2]string{model.KindGame, m.GameName}] {
+				row.Errors = append(row.Errors, "game "+m.GameName+" is in trash")
+			} else if createMissing && validName(m.GameName, 64) {
+				knownGames[m.GameName] = true
+				rep.MissingGames = append(rep.MissingGames, m.GameName)
+			} else {
+				row.Errors = append(row.Errors, "unknown game "+m.GameName)
+			}
+		}
+
+		for _, name := range []string{m.Winner, m.Loser} {
+			if name == "" || knownPlayers[name] {
+				continue
+			}
+			if inTrash[[2]string{model.KindPlayer, name}] {
+				row.Errors = append(row.Errors, "player "+name+" is in trash")
+			} else if createMissing && validName(name, 16) {
+				knownPlayers[name] = true
+				rep.MissingPlayers = append(rep.MissingPlayers, name)
+			} else {
+				row.Errors = append(row.Errors, "unknown player "+name)
+			}
+		}
+
+		if !row.Valid() {
+			rep.Invalid++
+			continue
+		}
+
+		key := matchKey(m)
+		if seen[key] {
+			row.Duplicate = true
+			rep.Duplicates++
+		}
+		seen[key] = true
+	}
+
+	return rep
+}
+
+// ParseDate returns date in TimeFormat
+func ParseDate(value string) (string, error) {
+	for _, layout := range dateFormats {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t.UTC().Format(TimeFormat), nil
+		}
+	}
+	return "", fmt.Errorf("bad date %q", value)
+}
+
+// matchKey is same for matches played at same time by same players
+func matchKey(m model.Match) string {
+	added, err := ParseDate(m.Added)
+	if err != nil {
+		added = m.Added
+	}
+
+	winner, loser := m.Winner, m.Loser
+	// Order doesn't matter in ties
+	if m.IsTie && loser < winner {
+		winner, loser = loser, winner
+	}
+
+	return strings.Join([]string{added, m.GameName, winner, loser, strconv.FormatBool(m.IsTie)}, "\x00")
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "0", "false", "no", "n":
+		return false, nil
+	case "1", "true", "yes", "y", "x", "tie":
+		return true, nil
+	}
+	return false, fmt.Errorf("bad tie value %q", value)
+}
+
+func parseScore(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	score, err := strconv.Atoi(value)
+	if err != nil || score < 0 {
+		return nil, fmt.Errorf("bad score %q", value)
+	}
+	return &score, nil
+}
+
+// validName follows constraints in database schema
+func validName(name string, max int) bool {
+	length := utf8.RuneCountInString(name)
+	return length >= 2 && length <= max
+}
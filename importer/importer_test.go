@@ -0,0 +1,340 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+func TestReadCSV(t *testing.T) {
+	three, one := 3, 1
+
+	tests := []struct {
+		name   string
+		input  string
+		match  model.Match
+		errors []string
+	}{
+		{
+			name:  "required columns",
+			input: "date,game,winner,loser\n2020-01-02,chess,alice,bob\n",
+			match: model.Match{GameName: "chess", Winner: "alice", Loser: "bob", Comment: "EMPTY", Added: "2020-01-02 00:00:00"},
+		},
+		{
+			name:  "columns in any order and case",
+			input: "Loser, WINNER ,Comment,Game,Date,Tie\nbob,alice,good game,chess,2020-01-02T10:30:00Z,yes\n",
+			match: model.Match{GameName: "chess", Winner: "alice", Loser: "bob", Comment: "good game", Added: "2020-01-02 10:30:00", IsTie: true},
+		},
+		{
+			name:  "scores",
+			input: "date,game,winner,loser,winner_score,loser_score\n2020-01-02 10:30,chess,alice,bob,3,1\n",
+			match: model.Match{GameName: "chess", Winner: "alice", Loser: "bob", Comment: "EMPTY", Added: "2020-01-02 10:30:00", WinnerScore: &three, LoserScore: &one},
+		},
+		{
+			name:   "short row",
+			input:  "date,game,winner,loser,comment\n2020-01-02,chess,alice\n",
+			match:  model.Match{GameName: "chess", Winner: "alice", Comment: "EMPTY", Added: "2020-01-02 00:00:00"},
+			errors: []string{"winner and loser required"},
+		},
+		{
+			name:   "bad values",
+			input:  "date,game,winner,loser,tie,winner_score\n02/01/2020,,alice,Alice,maybe,-1\n",
+			match:  model.Match{Winner: "alice", Loser: "Alice", Comment: "EMPTY"},
+			errors: []string{`bad date "02/01/2020"`, `bad tie value "maybe"`, `bad score "-1"`, "game is empty", "winner and loser cant be same player"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadCSV(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+
+			row := rows[0]
+			if row.Line != 2 {
+				t.Errorf("got line %d, want 2", row.Line)
+			}
+			tt.match.Status = model.MatchConfirmed
+			if !reflect.DeepEqual(row.Match, tt.match) {
+				t.Errorf("got match %+v, want %+v", row.Match, tt.match)
+			}
+			if len(row.Errors) != len(tt.errors) || (len(tt.errors) > 0 && !reflect.DeepEqual(row.Errors, tt.errors)) {
+				t.Errorf("got errors %q, want %q", row.Errors, tt.errors)
+			}
+		})
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"empty", "", "file is empty"},
+		{"missing column", "date,game,winner\n2020-01-02,chess,alice\n", `column "loser" is missing`},
+		{"broken quotes", "date,game,winner,loser\n2020-01-02,\"chess,alice,bob\n", "extraneous or missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadCSV(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"2020-01-02 03:04:05", "2020-01-02 03:04:05", true},
+		{"2020-01-02T03:04:05+02:00", "2020-01-02 01:04:05", true},
+		{"2020-01-02T03:04:05", "2020-01-02 03:04:05", true},
+		{"2020-01-02T03:04", "2020-01-02 03:04:00", true},
+		{"2020-01-02 03:04", "2020-01-02 03:04:00", true},
+		{"2020-01-02", "2020-01-02 00:00:00", true},
+		{"", "", false},
+		{"2020-13-01", "", false},
+		{"yesterday", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDate(tt.value)
+			if (err == nil) != tt.ok || got != tt.want {
+				t.Errorf("got %q and error %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// checkRows are read from CSV so tests show what file looks like
+func checkRows(t *testing.T, lines ...string) []Row {
+	rows, err := ReadCSV(strings.NewReader("date,game,winner,loser,tie\n" + strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestCheck(t *testing.T) {
+	players := []model.Player{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}}
+	games := []model.Game{{ID: 1, Name: "chess"}}
+	trashed := []model.Deleted{{Kind: model.KindPlayer, ID: 3, Name: "mallory"}, {Kind: model.KindGame, ID: 2, Name: "darts"}}
+	existing := []model.Match{{GameName: "chess", Winner: "alice", Loser: "bob", Added: "2020-01-01 00:00:00"}}
+
+	tests := []struct {
+		name           string
+		lines          []string
+		createMissing  bool
+		errors         [][]string
+		duplicates     []bool
+		missingPlayers []string
+		missingGames   []string
+	}{
+		{
+			name:       "known names",
+			lines:      []string{"2020-01-02,chess,alice,bob,"},
+			errors:     [][]string{nil},
+			duplicates: []bool{false},
+		},
+		{
+			name:       "unknown names",
+			lines:      []string{"2020-01-02,go,carol,bob,"},
+			errors:     [][]string{{"unknown game go", "unknown player carol"}},
+			duplicates: []bool{false},
+		},
+		{
+			name:           "create missing once",
+			lines:          []string{"2020-01-02,go,carol,bob,", "2020-01-03,go,bob,carol,"},
+			createMissing:  true,
+			errors:         [][]string{nil, nil},
+			duplicates:     []bool{false, false},
+			missingPlayers: []string{"carol"},
+			missingGames:   []string{"go"},
+		},
+		{
+			name:          "invalid names aren't created",
+			lines:         []string{"2020-01-02,g,c,bob,"},
+			createMissing: true,
+			errors:        [][]string{{"unknown game g", "unknown player c"}},
+			duplicates:    []bool{false},
+		},
+		{
+			name:          "names in trash",
+			lines:         []string{"2020-01-02,darts,mallory,bob,"},
+			createMissing: true,
+			errors:        [][]string{{"game darts is in trash", "player mallory is in trash"}},
+			duplicates:    []bool{false},
+		},
+		{
+			name:       "names in trash without create",
+			lines:      []string{"2020-01-02,darts,mallory,bob,"},
+			errors:     [][]string{{"game darts is in trash", "player mallory is in trash"}},
+			duplicates: []bool{false},
+		},
+		{
+			name:       "already saved",
+			lines:      []string{"2020-01-01T00:00:00Z,chess,alice,bob,"},
+			errors:     [][]string{nil},
+			duplicates: []bool{true},
+		},
+		{
+			name:       "twice in file, tie either way",
+			lines:      []string{"2020-01-02,chess,alice,bob,1", "2020-01-02,chess,bob,alice,1", "2020-01-02,chess,bob,alice,"},
+			errors:     [][]string{nil, nil, nil},
+			duplicates: []bool{false, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := Check(checkRows(t, tt.lines...), players, games, trashed, existing, tt.createMissing)
+
+			invalid, duplicates := 0, 0
+			for i, row := range rep.Rows {
+				if len(row.Errors) != len(tt.errors[i]) || (len(row.Errors) > 0 && !reflect.DeepEqual(row.Errors, tt.errors[i])) {
+					t.Errorf("row %d: got errors %q, want %q", i, row.Errors, tt.errors[i])
+				}
+				if row.Duplicate != tt.duplicates[i] {
+					t.Errorf("row %d: got duplicate %v, want %v", i, row.Duplicate, tt.duplicates[i])
+				}
+				if !row.Valid() {
+					invalid++
+				}
+				if row.Duplicate {
+					duplicates++
+				}
+			}
+
+			if rep.Invalid != invalid || rep.Duplicates != duplicates {
+				t.Errorf("got %d invalid and %d duplicates, want %d and %d", rep.Invalid, rep.Duplicates, invalid, duplicates)
+			}
+			if rep.CanImport() != (invalid == 0) {
+				t.Errorf("got CanImport %v with %d invalid", rep.CanImport(), invalid)
+			}
+			if !reflect.DeepEqual(rep.MissingPlayers, tt.missingPlayers) {
+				t.Errorf("got missing players %q, want %q", rep.MissingPlayers, tt.missingPlayers)
+			}
+			if !reflect.DeepEqual(rep.MissingGames, tt.missingGames) {
+				t.Errorf("got missing games %q, want %q", rep.MissingGames, tt.missingGames)
+			}
+		})
+	}
+}
+
+func TestReportImport(t *testing.T) {
+	players := []model.Player{{Name: "alice"}, {Name: "bob"}}
+	games := []model.Game{{Name: "chess"}}
+	rows := checkRows(t,
+		"2020-01-02,chess,alice,bob,",
+		"2020-01-02,chess,alice,bob,",
+		"2020-01-03,chess,alice,carol,",
+		"bad,chess,alice,bob,",
+	)
+
+	tests := []struct {
+		name          string
+		createMissing bool
+		canImport     bool
+		matches       int
+		players       []string
+	}{
+		// Dry run shows errors, nothing can be committed
+		{"dry run", false, false, 1, nil},
+		{"create missing", true, false, 2, []string{"carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copied := make([]Row, len(rows))
+			for i, row := range rows {
+				row.Errors = append([]string(nil), row.Errors...)
+				copied[i] = row
+			}
+
+			rep := Check(copied, players, games, nil, nil, tt.createMissing)
+			if rep.CanImport() != tt.canImport {
+				t.Errorf("got CanImport %v, want %v", rep.CanImport(), tt.canImport)
+			}
+
+			imp := rep.Import()
+			if len(imp.Matches) != tt.matches {
+				t.Errorf("got %d matches, want %d", len(imp.Matches), tt.matches)
+			}
+			if !reflect.DeepEqual(imp.Players, tt.players) {
+				t.Errorf("got players %q, want %q", imp.Players, tt.players)
+			}
+			for _, m := range imp.Matches {
+				if m.Added == "" || m.Status != model.MatchConfirmed {
+					t.Errorf("imported bad match %+v", m)
+				}
+			}
+		})
+	}
+}
+
+func TestReadPGN(t *testing.T) {
+	aliases := []model.Alias{{Alias: "Magnus", PlayerName: "alice"}}
+
+	tests := []struct {
+		name   string
+		tags   string
+		match  model.Match
+		errors []string
+	}{
+		{
+			name:  "white wins with alias",
+			tags:  `[White "magnus"] [Black "bob"] [Result "1-0"] [Date "2020.01.02"] [Event "Club"] [Site "?"]`,
+			match: model.Match{GameName: "chess", Winner: "alice", Loser: "bob", Added: "2020-01-02 00:00:00", Comment: "Club"},
+		},
+		{
+			name:  "black wins with UTC time",
+			tags:  `[White "bob"] [Black "carol"] [Result "0-1"] [Date "2020.01.02"] [UTCDate "2020.01.03"] [UTCTime "10:30:00"]`,
+			match: model.Match{GameName: "chess", Winner: "carol", Loser: "bob", Added: "2020-01-03 10:30:00", Comment: "EMPTY"},
+		},
+		{
+			name:  "draw with unknown day",
+			tags:  `[White "bob"] [Black "carol"] [Result "1/2-1/2"] [Date "2020.02.??"] [Event "Open"] [Site "Helsinki"]`,
+			match: model.Match{GameName: "chess", Winner: "bob", Loser: "carol", IsTie: true, Added: "2020-02-01 00:00:00", Comment: "Open, Helsinki"},
+		},
+		{
+			name:   "unfinished without date",
+			tags:   `[White "bob"] [Black "carol"] [Result "*"] [Date "????.??.??"]`,
+			match:  model.Match{GameName: "chess", Winner: "bob", Loser: "carol", Comment: "EMPTY"},
+			errors: []string{`no result "*"`, `bad date "????.??.??"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := strings.Replace(tt.tags, "] [", "]\n[", -1) + "\n\n1. e4 *\n"
+			rows, err := ReadPGN(strings.NewReader(input), "chess", aliases)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1", len(rows))
+			}
+
+			tt.match.Status = model.MatchConfirmed
+			if !reflect.DeepEqual(rows[0].Match, tt.match) {
+				t.Errorf("got match %+v, want %+v", rows[0].Match, tt.match)
+			}
+			if len(rows[0].Errors) != len(tt.errors) || (len(tt.errors) > 0 && !reflect.DeepEqual(rows[0].Errors, tt.errors)) {
+				t.Errorf("got errors %q, want %q", rows[0].Errors, tt.errors)
+			}
+		})
+	}
+}
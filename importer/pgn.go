@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tuommii/jumbo/model"
+	"github.com/tuommii/jumbo/pgn"
+)
+
+// ReadPGN returns rows for games in PGN, all played as gameName. Player
+// names are mapped through aliases, which are matched case-insensitively.
+func ReadPGN(r io.Reader, gameName string, aliases []model.Alias) ([]Row, error) {
+	games, err := pgn.Read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, a := range aliases {
+		names[strings.ToLower(a.Alias)] = a.PlayerName
+	}
+	player := func(name string) string {
+		if mapped, ok := names[strings.ToLower(name)]; ok {
+			return mapped
+		}
+		return name
+	}
+
+	rows := make([]Row, 0, len(games))
+
+	for _, g := range games {
+		white := player(g.Tag("White"))
+		black := player(g.Tag("Black"))
+		errs := make([]string, 0)
+
+		m := model.Match{GameName: gameName, Comment: pgnComment(g)}
+
+		switch g.Tag("Result") {
+		case pgn.WhiteWins:
+			m.Winner, m.Loser = white, black
+		case pgn.BlackWins:
+			m.Winner, m.Loser = black, white
+		case pgn.Draw:
+			m.Winner, m.Loser = white, black
+			m.IsTie = true
+		default:
+			m.Winner, m.Loser = white, black
+			errs = append(errs, fmt.Sprintf("no result %q", g.Tag("Result")))
+		}
+
+		added, err := pgnDate(g)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		m.Added = added
+
+		rows = append(rows, NewRow(g.Line, m, errs...))
+	}
+
+	return rows, nil
+}
+
+// pgnDate prefers UTC tags written by online sites. Unknown month or day
+// is first of year or month.
+func pgnDate(g pgn.Game) (string, error) {
+	date, clock := g.Tag("UTCDate"), g.Tag("UTCTime")
+	if date == "" {
+		date, clock = g.Tag("Date"), g.Tag("Time")
+	}
+
+	parts := strings.Split(date, ".")
+	if len(parts) != 3 || strings.Contains(parts[0], "?") {
+		return "", fmt.Errorf("bad date %q", date)
+	}
+	for i := 1; i < 3; i++ {
+		if strings.Contains(parts[i], "?") {
+			parts[i] = "01"
+		}
+	}
+
+	value := strings.Join(parts, "-")
+	if clock != "" && !strings.Contains(clock, "?") {
+		value += " " + clock
+	}
+	return ParseDate(value)
+}
+
+// pgnComment keeps where game was played
+func pgnComment(g pgn.Game) string {
+	comment := make([]string, 0, 2)
+	for _, tag := range []string{"Event", "Site"} {
+		value := g.Tag(tag)
+		if value != "" && value != "?" {
+			comment = append(comment, value)
+		}
+	}
+	return strings.Join(comment, ", ")
+}
@@ -1,22 +1,16 @@
 package main
 
 import (
-	"log"
+	"fmt"
+	"os"
 
-	"github.com/tuommii/jumbo/database"
-	"github.com/tuommii/jumbo/server"
+	"github.com/tuommii/jumbo/cli"
 )
 
 func main() {
-	db, err := database.NewSQLiteDB("jumbo.db")
+	err := cli.Run(os.Args[1:])
 	if err != nil {
-		panic(err)
-	}
-	defer db.Connection.Close()
-
-	server := server.Create(db)
-	err = server.Start()
-	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,277 @@
+// Package metrics keeps counters, gauges and histograms and writes them in
+// Prometheus text format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType of Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets for latencies in seconds
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector writes its metrics
+type Collector interface {
+	Write(w io.Writer) error
+}
+
+// Registry holds collectors written together
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// Default registry for metrics defined at package level
+var Default = &Registry{}
+
+// Register adds collectors to registry
+func (r *Registry) Register(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, cs...)
+}
+
+// Write writes all collectors in order they were registered
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	cs := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range cs {
+		err := c.Write(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// desc is metric's name, help and label names
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d desc) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, kind)
+	return err
+}
+
+// key joins label values, it's also used to write them back
+func key(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// labelString formats labels as {a="1",b="2"}, extra is appended as is
+func (d desc) labelString(k string, extra string) string {
+	pairs := make([]string, 0, len(d.labels)+1)
+	if len(d.labels) > 0 {
+		for i, value := range strings.Split(k, "\xff") {
+			pairs = append(pairs, d.labels[i]+`="`+escapeValue(value)+`"`)
+		}
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (d desc) check(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+}
+
+// CounterVec is counter for every combination of label values
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec returns counter, register it to be written
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	return &CounterVec{desc: desc{name, help, labels}, values: make(map[string]float64)}
+}
+
+// Inc adds one to counter with label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v to counter with label values
+func (c *CounterVec) Add(v float64, values ...string) {
+	c.check(values)
+	c.mu.Lock()
+	c.values[key(values)] += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) Write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return writeValues(w, c.desc, "counter", c.values)
+}
+
+// GaugeVec is value for every combination of label values
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewGaugeVec returns gauge, register it to be written
+func NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{desc: desc{name, help, labels}, values: make(map[string]float64)}
+}
+
+// Set sets gauge with label values
+func (g *GaugeVec) Set(v float64, values ...string) {
+	g.check(values)
+	g.mu.Lock()
+	g.values[key(values)] = v
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) Write(w io.Writer) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return writeValues(w, g.desc, "gauge", g.values)
+}
+
+func writeValues(w io.Writer, d desc, kind string, values map[string]float64) error {
+	err := d.header(w, kind)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range sortedKeys(values) {
+		_, err := fmt.Fprintf(w, "%s%s %s\n", d.name, d.labelString(k, ""), formatFloat(values[k]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HistogramVec counts observations in buckets for every combination of
+// label values
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec returns histogram with upper bounds of buckets in
+// increasing order, register it to be written
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{desc: desc{name, help, labels}, buckets: buckets, values: make(map[string]*histogram)}
+}
+
+// Observe adds v to histogram with label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.check(values)
+	k := key(values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist, ok := h.values[k]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[k] = hist
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += v
+}
+
+func (h *HistogramVec) Write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	err := h.header(w, "histogram")
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		hist := h.values[k]
+		for i, bound := range h.buckets {
+			le := `le="` + formatFloat(bound) + `"`
+			_, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(k, le), hist.counts[i])
+			if err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelString(k, `le="+Inf"`), hist.count,
+			h.name, h.labelString(k, ""), formatFloat(hist.sum),
+			h.name, h.labelString(k, ""), hist.count,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
+
+func escapeValue(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
@@ -0,0 +1,48 @@
+package model
+
+// Audit actions
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionConfirm = "confirm"
+	ActionReject  = "reject"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionImport  = "import"
+)
+
+// Audited entities besides players, games and matches
+const (
+	EntityChallenge = "challenge"
+	EntityAlias     = "alias"
+	EntityEvent     = "event"
+	EntityShareLink = "share_link"
+	EntityWebhook   = "webhook"
+	EntityChatUser  = "chat_user"
+)
+
+// AuditEntry is one recorded write. Before and After are JSON snapshots of
+// entity, "null" when there is nothing to show.
+type AuditEntry struct {
+	ID         int    `json:"id"`
+	Created    string `json:"created"`
+	Actor      string `json:"actor"`
+	RemoteAddr string `json:"remoteAddr"`
+	RequestID  string `json:"requestId"`
+	Action     string `json:"action"`
+	Entity     string `json:"entity"`
+	EntityID   int    `json:"entityId"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+}
+
+// AuditFilter query, zero values match everything
+type AuditFilter struct {
+	Actor     string
+	Action    string
+	Entity    string
+	EntityID  int
+	LimitDays int
+	Limit     int
+}
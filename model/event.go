@@ -0,0 +1,23 @@
+package model
+
+// ScheduledEvent is league fixture or game night, with two players it's
+// match to be played
+type ScheduledEvent struct {
+	ID       int      `json:"id"`
+	GameName string   `json:"gameName"`
+	Title    string   `json:"title"`
+	Players  []string `json:"players"`
+	Starts   string   `json:"starts"`
+	Ends     string   `json:"ends"`
+	Location string   `json:"location"`
+	Created  string   `json:"created"`
+}
+
+// EventFilter selects scheduled events, empty fields match everything
+type EventFilter struct {
+	GameName string
+	Player   string
+	// Events that ended before this many days ago are left out, zero
+	// leaves out everything that has ended
+	PastDays int
+}
@@ -1,6 +1,12 @@
 package model
 
-import "strconv"
+import (
+	"strconv"
+	"time"
+)
+
+// Format of added in database
+const addedFormat = "2006-01-02 15:04:05"
 
 // Filter query
 type Filter struct {
@@ -9,62 +15,54 @@ type Filter struct {
 	Player2    string
 	LimitDays  int
 	LimitGames int
+	// Matches added at or after From and before To, zero is open
+	From time.Time
+	To   time.Time
 }
 
-// PlayerCount returns number of players
-func (f *Filter) playerCount() int {
-	count := 0
+// GetQuery returns SQL-query based on filters and its arguments. Values
+// are passed as arguments, never written into query.
+func (f *Filter) GetQuery() (string, []interface{}) {
+	query := "SELECT id, game_name, is_tie, winner, loser, comment, added, winner_score, loser_score FROM match"
+	args := make([]interface{}, 0)
 
-	if f.Player1 != "" {
-		count++
-	}
+	query += " WHERE (status = ?) AND (deleted_at IS NULL)"
+	args = append(args, MatchConfirmed)
 
-	if f.Player2 != "" {
-		count++
+	for _, player := range []string{f.Player1, f.Player2} {
+		if player != "" {
+			query += " AND (winner LIKE ? OR loser LIKE ?)"
+			args = append(args, player, player)
+		}
 	}
 
-	return count
-}
-
-// GetQuery returns SQL-query based on filters
-// FIXME: Refactor
-func (f *Filter) GetQuery() string {
-	query := "SELECT id, game_name, is_tie, winner, loser, comment, added FROM match"
-
-	// Needed so "AND" works in query
-	noPlayers := " WHERE 1=1"
-	onePlayer := ` WHERE (winner LIKE "` + f.Player1 + `" OR loser LIKE "` + f.Player1 + `")`
-	twoPlayer := ` WHERE (winner LIKE "` + f.Player1 + `" OR loser LIKE "` + f.Player1 + `") AND (winner LIKE "` + f.Player2 + `" OR loser LIKE "` + f.Player2 + `")`
-	gameName := ` AND (game_name LIKE "` + f.GameName + `")`
-	order := " ORDER BY added DESC"
-	limitDays := " AND (added > (SELECT DATETIME('now', '-" + strconv.Itoa(f.LimitDays) + " day')))"
-
-	limitGames := " LIMIT " + strconv.Itoa(f.LimitGames)
+	if f.GameName != "" {
+		query += " AND (game_name LIKE ?)"
+		args = append(args, f.GameName)
+	}
 
-	switch f.playerCount() {
-	case 0:
-		query = query + noPlayers
-	case 1:
-		query = query + onePlayer
-	case 2:
-		query = query + twoPlayer
-	default:
-		return ""
+	if f.LimitDays > 0 {
+		query += " AND (added > DATETIME('now', ?))"
+		args = append(args, "-"+strconv.Itoa(f.LimitDays)+" day")
 	}
 
-	if f.GameName != "" {
-		query = query + gameName
+	// Imported matches may have other time format, DATETIME reads both
+	if !f.From.IsZero() {
+		query += " AND (DATETIME(added) >= ?)"
+		args = append(args, f.From.UTC().Format(addedFormat))
 	}
 
-	if f.LimitDays > 0 {
-		query = query + limitDays
+	if !f.To.IsZero() {
+		query += " AND (DATETIME(added) < ?)"
+		args = append(args, f.To.UTC().Format(addedFormat))
 	}
 
-	query = query + order
+	query += " ORDER BY added DESC"
 
 	if f.LimitGames > 0 {
-		query = query + limitGames
+		query += " LIMIT ?"
+		args = append(args, f.LimitGames)
 	}
 
-	return query
+	return query, args
 }
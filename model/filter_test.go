@@ -0,0 +1,69 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterGetQuery(t *testing.T) {
+	from := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	injection := `x") UNION SELECT name, value FROM secret--`
+
+	tests := []struct {
+		name   string
+		filter Filter
+		where  string
+		args   []interface{}
+	}{
+		{
+			name:   "no filter",
+			filter: Filter{},
+			where:  " WHERE (status = ?) AND (deleted_at IS NULL) ORDER BY added DESC",
+			args:   []interface{}{MatchConfirmed},
+		},
+		{
+			name:   "two players and game",
+			filter: Filter{GameName: "chess", Player1: "alice", Player2: "bob"},
+			where:  " WHERE (status = ?) AND (deleted_at IS NULL) AND (winner LIKE ? OR loser LIKE ?) AND (winner LIKE ? OR loser LIKE ?) AND (game_name LIKE ?) ORDER BY added DESC",
+			args:   []interface{}{MatchConfirmed, "alice", "alice", "bob", "bob", "chess"},
+		},
+		{
+			name:   "second player only",
+			filter: Filter{Player2: "bob"},
+			where:  " WHERE (status = ?) AND (deleted_at IS NULL) AND (winner LIKE ? OR loser LIKE ?) ORDER BY added DESC",
+			args:   []interface{}{MatchConfirmed, "bob", "bob"},
+		},
+		{
+			name:   "limits and dates",
+			filter: Filter{LimitDays: 30, LimitGames: 10, From: from, To: to},
+			where:  " WHERE (status = ?) AND (deleted_at IS NULL) AND (added > DATETIME('now', ?)) AND (DATETIME(added) >= ?) AND (DATETIME(added) < ?) ORDER BY added DESC LIMIT ?",
+			args:   []interface{}{MatchConfirmed, "-30 day", "2020-01-02 00:00:00", "2020-02-01 00:00:00", 10},
+		},
+		{
+			name:   "values stay out of query",
+			filter: Filter{GameName: injection, Player1: injection},
+			where:  " WHERE (status = ?) AND (deleted_at IS NULL) AND (winner LIKE ? OR loser LIKE ?) AND (game_name LIKE ?) ORDER BY added DESC",
+			args:   []interface{}{MatchConfirmed, injection, injection, injection},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.filter.GetQuery()
+
+			where := query[strings.Index(query, " WHERE"):]
+			if where != tt.where {
+				t.Errorf("got query\n%s\nwant\n%s", where, tt.where)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("got args %v, want %v", args, tt.args)
+			}
+			if strings.Count(query, "?") != len(args) {
+				t.Errorf("%d placeholders for %d args", strings.Count(query, "?"), len(args))
+			}
+		})
+	}
+}
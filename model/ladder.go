@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Challenge statuses
+const (
+	ChallengePending   = "pending"
+	ChallengeAccepted  = "accepted"
+	ChallengeDeclined  = "declined"
+	ChallengePlayed    = "played"
+	ChallengeForfeited = "forfeited"
+)
+
+// MaxChallengeDistance is how many ranks above yourself you may challenge
+const MaxChallengeDistance = 3
+
+// ChallengeDeadline is time to answer and play a challenge
+const ChallengeDeadline = 7 * 24 * time.Hour
+
+// Errors when creating or answering challenge
+var (
+	ErrChallengeRange  = errors.New("opponent must be ranked above challenger and close enough")
+	ErrChallengeOpen   = errors.New("player already has an open challenge in this game")
+	ErrChallengePlayer = errors.New("challenger and opponent must be players")
+	ErrNotChallenged   = errors.New("only opponent can answer challenge")
+	ErrNoChallenge     = errors.New("no such challenge")
+)
+
+// Rung is player's position in game's ladder, 1 is top
+type Rung struct {
+	GameName string `json:"gameName"`
+	Rank     int    `json:"rank"`
+	Player   string `json:"player"`
+}
+
+// Challenge is challenger's request to play opponent ranked above
+type Challenge struct {
+	ID         int    `json:"id"`
+	GameName   string `json:"gameName"`
+	Challenger string `json:"challenger"`
+	Opponent   string `json:"opponent"`
+	Status     string `json:"status"`
+	MatchID    int    `json:"matchId,omitempty"`
+	Created    string `json:"created"`
+	Deadline   string `json:"deadline"`
+}
+
+// IsOpen tells if challenge is waiting for answer or result
+func (c Challenge) IsOpen() bool {
+	return c.Status == ChallengePending || c.Status == ChallengeAccepted
+}
+
+// CanChallenge tells if challenger may challenge opponent based on ranks
+func CanChallenge(challengerRank, opponentRank int) bool {
+	distance := challengerRank - opponentRank
+	return distance > 0 && distance <= MaxChallengeDistance
+}
@@ -0,0 +1,426 @@
+package model
+
+import "sort"
+
+// League formats
+const (
+	RoundRobinFormat       = "roundrobin"
+	DoubleRoundRobinFormat = "double"
+	SwissFormat            = "swiss"
+)
+
+const (
+	// MaxLeaguePlayers is most players league can have
+	MaxLeaguePlayers = 64
+	// Pairing search gives up after this many steps and pairs greedily
+	maxPairingSteps = 10000
+)
+
+// Fixture is a scheduled pairing between two players
+type Fixture struct {
+	Round    int    `json:"round"`
+	GameName string `json:"gameName"`
+	Home     string `json:"home"`
+	// Empty when Home has a bye
+	Away    string `json:"away"`
+	MatchID int    `json:"matchId,omitempty"`
+	Played  bool   `json:"played"`
+}
+
+// IsBye tells if fixture is a bye round for Home
+func (f Fixture) IsBye() bool {
+	return f.Away == ""
+}
+
+// Standing is row in league table
+type Standing struct {
+	Name            string  `json:"name"`
+	Played          int     `json:"played"`
+	Wins            int     `json:"wins"`
+	Ties            int     `json:"ties"`
+	Losses          int     `json:"losses"`
+	Byes            int     `json:"byes"`
+	Points          float64 `json:"points"`
+	Buchholz        float64 `json:"buchholz"`
+	SonnebornBerger float64 `json:"sonnebornBerger"`
+}
+
+// Standings is sorted by points and tiebreakers
+type Standings []*Standing
+
+// Len is part of sort.Interface.
+func (s Standings) Len() int {
+	return len(s)
+}
+
+// Swap is part of sort.Interface.
+func (s Standings) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less is part of sort.Interface. Points first, then Buchholz and Sonneborn-Berger
+func (s Standings) Less(i, j int) bool {
+	if s[i].Points != s[j].Points {
+		return s[i].Points > s[j].Points
+	}
+	if s[i].Buchholz != s[j].Buchholz {
+		return s[i].Buchholz > s[j].Buchholz
+	}
+	if s[i].SonnebornBerger != s[j].SonnebornBerger {
+		return s[i].SonnebornBerger > s[j].SonnebornBerger
+	}
+	if s[i].Wins != s[j].Wins {
+		return s[i].Wins > s[j].Wins
+	}
+	return s[i].Name < s[j].Name
+}
+
+// RoundRobin returns fixtures where everyone plays everyone once, or twice
+// with home and away swapped when double is true. Uses circle method.
+func RoundRobin(players []Player, gameName string, double bool) []Fixture {
+	names := playerNames(players)
+	if len(names) < 2 {
+		return nil
+	}
+
+	// Odd count, empty name marks a bye
+	if len(names)%2 == 1 {
+		names = append(names, "")
+	}
+
+	n := len(names)
+	fixtures := make([]Fixture, 0, n*(n-1))
+
+	for round := 0; round < n-1; round++ {
+		for i := 0; i < n/2; i++ {
+			home, away := names[i], names[n-1-i]
+			// Alternate so nobody is always home
+			if (round+i)%2 == 1 {
+				home, away = away, home
+			}
+			if home == "" || away == "" {
+				continue
+			}
+			fixtures = append(fixtures, Fixture{Round: round + 1, GameName: gameName, Home: home, Away: away})
+		}
+		// Keep first fixed, rotate rest clockwise
+		last := names[n-1]
+		copy(names[2:], names[1:n-1])
+		names[1] = last
+	}
+
+	if double {
+		count := len(fixtures)
+		for _, f := range fixtures[:count] {
+			fixtures = append(fixtures, Fixture{Round: f.Round + n - 1, GameName: gameName, Home: f.Away, Away: f.Home})
+		}
+	}
+
+	return fixtures
+}
+
+// MarkPlayed links fixtures to recorded matches between same players.
+// Oldest match goes to earliest fixture, each match is used once.
+func MarkPlayed(fixtures []Fixture, matches []Match) {
+	used := make(map[int]bool)
+	ordered := oldestFirst(matches)
+
+	for i := range fixtures {
+		if fixtures[i].IsBye() {
+			fixtures[i].Played = true
+			continue
+		}
+		m := findMatch(ordered, used, fixtures[i])
+		if m != nil {
+			used[m.ID] = true
+			fixtures[i].MatchID = m.ID
+			fixtures[i].Played = true
+		}
+	}
+}
+
+// Swiss returns fixtures for completed rounds and the current round. Each
+// round is paired from standings after previous rounds, so already generated
+// rounds don't change when new matches are recorded. Generation stops at
+// first round that still has unplayed fixtures. Rounds are capped at
+// SwissRounds.
+func Swiss(players []Player, gameName string, matches []Match, rounds int) []Fixture {
+	names := playerNames(players)
+	if len(names) < 2 {
+		return nil
+	}
+	if rounds > SwissRounds(len(names)) {
+		rounds = SwissRounds(len(names))
+	}
+
+	fixtures := make([]Fixture, 0)
+	used := make(map[int]bool)
+	ordered := oldestFirst(matches)
+
+	for round := 1; round <= rounds; round++ {
+		standings := LeagueStandings(players, fixtures, matches)
+		pairs := swissPairs(standings, fixtures)
+
+		complete := true
+		for _, pair := range pairs {
+			f := Fixture{Round: round, GameName: gameName, Home: pair[0], Away: pair[1]}
+			if f.IsBye() {
+				f.Played = true
+			} else if m := findMatch(ordered, used, f); m != nil {
+				used[m.ID] = true
+				f.MatchID = m.ID
+				f.Played = true
+			} else {
+				complete = false
+			}
+			fixtures = append(fixtures, f)
+		}
+
+		if !complete {
+			break
+		}
+	}
+
+	return fixtures
+}
+
+// SwissRounds returns most rounds players can play before everyone has met
+// everyone. With odd count someone has a bye every round, so it takes a round
+// more.
+func SwissRounds(players int) int {
+	if players%2 == 1 {
+		return players
+	}
+	return players - 1
+}
+
+// LeagueStandings calculates table from played fixtures. Bye counts as win
+// in points but not in Buchholz or Sonneborn-Berger.
+func LeagueStandings(players []Player, fixtures []Fixture, matches []Match) Standings {
+	table := make(map[string]*Standing)
+	for _, name := range playerNames(players) {
+		table[name] = &Standing{Name: name}
+	}
+
+	byID := make(map[int]Match)
+	for _, m := range matches {
+		byID[m.ID] = m
+	}
+
+	// Results from view of each player, needed for tiebreakers
+	type result struct {
+		opponent string
+		score    float64
+	}
+	results := make(map[string][]result)
+
+	for _, f := range fixtures {
+		if !f.Played {
+			continue
+		}
+		if f.IsBye() {
+			if s, ok := table[f.Home]; ok {
+				s.Byes++
+				s.Points++
+			}
+			continue
+		}
+
+		m, ok := byID[f.MatchID]
+		if !ok {
+			continue
+		}
+		winner, loser := table[m.Winner], table[m.Loser]
+		if winner == nil || loser == nil {
+			continue
+		}
+
+		winner.Played++
+		loser.Played++
+		if m.IsTie {
+			winner.Ties++
+			loser.Ties++
+			winner.Points += 0.5
+			loser.Points += 0.5
+			results[m.Winner] = append(results[m.Winner], result{m.Loser, 0.5})
+			results[m.Loser] = append(results[m.Loser], result{m.Winner, 0.5})
+		} else {
+			winner.Wins++
+			loser.Losses++
+			winner.Points++
+			results[m.Winner] = append(results[m.Winner], result{m.Loser, 1})
+			results[m.Loser] = append(results[m.Loser], result{m.Winner, 0})
+		}
+	}
+
+	standings := make(Standings, 0, len(table))
+	for name, s := range table {
+		for _, r := range results[name] {
+			opponentPoints := table[r.opponent].Points
+			s.Buchholz += opponentPoints
+			s.SonnebornBerger += r.score * opponentPoints
+		}
+		standings = append(standings, s)
+	}
+
+	sort.Sort(standings)
+	return standings
+}
+
+// GroupRounds splits fixtures by round, rounds in order
+func GroupRounds(fixtures []Fixture) [][]Fixture {
+	rounds := make([][]Fixture, 0)
+	index := make(map[int]int)
+
+	for _, f := range fixtures {
+		i, ok := index[f.Round]
+		if !ok {
+			i = len(rounds)
+			index[f.Round] = i
+			rounds = append(rounds, nil)
+		}
+		rounds[i] = append(rounds[i], f)
+	}
+
+	sort.SliceStable(rounds, func(i, j int) bool {
+		return rounds[i][0].Round < rounds[j][0].Round
+	})
+	return rounds
+}
+
+// swissPairs pairs players with similar scores avoiding rematches. If that
+// can't be done quickly, players are paired greedily and rematches are
+// allowed. Bye goes to lowest ranked player with fewest byes.
+func swissPairs(standings Standings, fixtures []Fixture) [][2]string {
+	met := make(map[[2]string]bool)
+	byes := make(map[string]int)
+	for _, f := range fixtures {
+		if f.IsBye() {
+			byes[f.Home]++
+			continue
+		}
+		met[[2]string{f.Home, f.Away}] = true
+		met[[2]string{f.Away, f.Home}] = true
+	}
+
+	names := make([]string, 0, len(standings))
+	for _, s := range standings {
+		names = append(names, s.Name)
+	}
+
+	var bye string
+	if len(names)%2 == 1 {
+		pick := len(names) - 1
+		for i := len(names) - 1; i >= 0; i-- {
+			if byes[names[i]] < byes[names[pick]] {
+				pick = i
+			}
+		}
+		bye = names[pick]
+		names = append(names[:pick], names[pick+1:]...)
+	}
+
+	steps := maxPairingSteps
+	pairs, ok := pairUp(names, met, &steps)
+	if !ok {
+		pairs = greedyPairs(names, met)
+	}
+
+	if bye != "" {
+		pairs = append(pairs, [2]string{bye, ""})
+	}
+	return pairs
+}
+
+// pairUp pairs first player with highest ranked allowed opponent, backtracks
+// when rest can't be paired. Fails when steps run out.
+func pairUp(names []string, met map[[2]string]bool, steps *int) ([][2]string, bool) {
+	if len(names) == 0 {
+		return nil, true
+	}
+
+	*steps--
+	if *steps < 0 {
+		return nil, false
+	}
+
+	first := names[0]
+	for i := 1; i < len(names); i++ {
+		if met[[2]string{first, names[i]}] {
+			continue
+		}
+
+		rest := make([]string, 0, len(names)-2)
+		rest = append(rest, names[1:i]...)
+		rest = append(rest, names[i+1:]...)
+
+		pairs, ok := pairUp(rest, met, steps)
+		if ok {
+			return append([][2]string{{first, names[i]}}, pairs...), true
+		}
+		if *steps < 0 {
+			break
+		}
+	}
+	return nil, false
+}
+
+// greedyPairs pairs first player with highest ranked opponent not met yet,
+// or with next player if it has met everyone
+func greedyPairs(names []string, met map[[2]string]bool) [][2]string {
+	rest := append([]string(nil), names...)
+	pairs := make([][2]string, 0, len(rest)/2)
+
+	for len(rest) > 1 {
+		pick := 1
+		for i := 1; i < len(rest); i++ {
+			if !met[[2]string{rest[0], rest[i]}] {
+				pick = i
+				break
+			}
+		}
+		pairs = append(pairs, [2]string{rest[0], rest[pick]})
+		rest = append(rest[1:pick], rest[pick+1:]...)
+	}
+	return pairs
+}
+
+// findMatch returns first unused match between fixture's players
+func findMatch(matches []Match, used map[int]bool, f Fixture) *Match {
+	for i := range matches {
+		m := &matches[i]
+		if used[m.ID] || m.GameName != f.GameName {
+			continue
+		}
+		if (m.Winner == f.Home && m.Loser == f.Away) || (m.Winner == f.Away && m.Loser == f.Home) {
+			return m
+		}
+	}
+	return nil
+}
+
+// oldestFirst returns copy of matches ordered by time added
+func oldestFirst(matches []Match) []Match {
+	ordered := make([]Match, len(matches))
+	copy(ordered, matches)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Added != ordered[j].Added {
+			return ordered[i].Added < ordered[j].Added
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+	return ordered
+}
+
+func playerNames(players []Player) []string {
+	names := make([]string, 0, len(players))
+	seen := make(map[string]bool)
+	for _, p := range players {
+		if p.Name == "" || seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+		names = append(names, p.Name)
+	}
+	return names
+}
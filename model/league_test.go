@@ -0,0 +1,225 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testPlayers(n int) []Player {
+	players := make([]Player, n)
+	for i := range players {
+		players[i] = Player{ID: i + 1, Name: fmt.Sprintf("p%02d", i+1)}
+	}
+	return players
+}
+
+func TestRoundRobin(t *testing.T) {
+	tests := []struct {
+		players  int
+		double   bool
+		fixtures int
+		rounds   int
+	}{
+		{1, false, 0, 0},
+		{2, false, 1, 1},
+		{3, false, 3, 3},
+		{4, false, 6, 3},
+		{5, false, 10, 5},
+		{4, true, 12, 6},
+		{5, true, 20, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d players double %v", tt.players, tt.double), func(t *testing.T) {
+			fixtures := RoundRobin(testPlayers(tt.players), "chess", tt.double)
+			if len(fixtures) != tt.fixtures {
+				t.Fatalf("got %d fixtures, want %d", len(fixtures), tt.fixtures)
+			}
+			if got := len(GroupRounds(fixtures)); got != tt.rounds {
+				t.Errorf("got %d rounds, want %d", got, tt.rounds)
+			}
+
+			meetings := make(map[[2]string]int)
+			perRound := make(map[int]map[string]bool)
+			for _, f := range fixtures {
+				if f.IsBye() {
+					t.Fatalf("bye in fixtures: %+v", f)
+				}
+				meetings[[2]string{f.Home, f.Away}]++
+				if perRound[f.Round] == nil {
+					perRound[f.Round] = make(map[string]bool)
+				}
+				for _, name := range []string{f.Home, f.Away} {
+					if perRound[f.Round][name] {
+						t.Errorf("%s plays twice in round %d", name, f.Round)
+					}
+					perRound[f.Round][name] = true
+				}
+			}
+
+			// Everyone meets once, in double once home and once away
+			players := testPlayers(tt.players)
+			for i, a := range players {
+				for _, b := range players[i+1:] {
+					ab, ba := meetings[[2]string{a.Name, b.Name}], meetings[[2]string{b.Name, a.Name}]
+					if tt.double && (ab != 1 || ba != 1) {
+						t.Errorf("%s-%s met %d+%d times, want 1+1", a.Name, b.Name, ab, ba)
+					}
+					if !tt.double && ab+ba != 1 {
+						t.Errorf("%s-%s met %d times, want 1", a.Name, b.Name, ab+ba)
+					}
+				}
+			}
+		})
+	}
+}
+
+// playRounds records win for home player of every unplayed fixture
+func playRounds(t *testing.T, players []Player, rounds int) ([]Fixture, []Match) {
+	t.Helper()
+
+	matches := make([]Match, 0)
+	added := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for {
+		fixtures := Swiss(players, "chess", matches, rounds)
+		unplayed := 0
+		for _, f := range fixtures {
+			if f.Played {
+				continue
+			}
+			unplayed++
+			added = added.Add(time.Minute)
+			matches = append(matches, Match{
+				ID:       len(matches) + 1,
+				GameName: "chess",
+				Winner:   f.Home,
+				Loser:    f.Away,
+				Added:    added.Format(time.RFC3339),
+			})
+		}
+		if unplayed == 0 {
+			return fixtures, matches
+		}
+	}
+}
+
+func TestSwissByes(t *testing.T) {
+	tests := []struct {
+		players int
+		rounds  int
+	}{
+		{3, 2},
+		{5, 4},
+		{5, 5},
+		{7, 5},
+		// Capped at SwissRounds
+		{4, 100},
+		{5, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d players %d rounds", tt.players, tt.rounds), func(t *testing.T) {
+			fixtures, _ := playRounds(t, testPlayers(tt.players), tt.rounds)
+
+			rounds := GroupRounds(fixtures)
+			want := tt.rounds
+			if want > SwissRounds(tt.players) {
+				want = SwissRounds(tt.players)
+			}
+			if len(rounds) != want {
+				t.Fatalf("got %d rounds, want %d", len(rounds), want)
+			}
+
+			byes := make(map[string]int)
+			for _, round := range rounds {
+				roundByes := 0
+				for _, f := range round {
+					if f.IsBye() {
+						roundByes++
+						byes[f.Home]++
+						if !f.Played {
+							t.Errorf("bye of %s is not played", f.Home)
+						}
+					}
+				}
+				if roundByes != tt.players%2 {
+					t.Errorf("round %d has %d byes, want 1", round[0].Round, roundByes)
+				}
+			}
+
+			// Nobody gets second bye before everyone had one
+			for name, n := range byes {
+				if n > 1 {
+					t.Errorf("%s got %d byes", name, n)
+				}
+			}
+		})
+	}
+}
+
+func TestSwissNoRematches(t *testing.T) {
+	fixtures, _ := playRounds(t, testPlayers(8), 3)
+
+	met := make(map[[2]string]bool)
+	for _, f := range fixtures {
+		key := [2]string{f.Home, f.Away}
+		if f.Home > f.Away {
+			key = [2]string{f.Away, f.Home}
+		}
+		if met[key] {
+			t.Errorf("rematch %s-%s in round %d", f.Home, f.Away, f.Round)
+		}
+		met[key] = true
+	}
+}
+
+func TestSwissPairsRematchFallback(t *testing.T) {
+	players := testPlayers(4)
+	standings := LeagueStandings(players, nil, nil)
+
+	// Everyone has met everyone, only rematches are left
+	fixtures := RoundRobin(players, "chess", false)
+	pairs := swissPairs(standings, fixtures)
+
+	if len(pairs) != 2 {
+		t.Fatalf("got %d pairs, want 2", len(pairs))
+	}
+	seen := make(map[string]bool)
+	for _, pair := range pairs {
+		for _, name := range pair {
+			if name == "" || seen[name] {
+				t.Fatalf("bad pairs %v", pairs)
+			}
+			seen[name] = true
+		}
+	}
+}
+
+func TestSwissPairsBounded(t *testing.T) {
+	// Last player has met everyone, so no pairing avoids rematches. Full
+	// search would try every pairing of the others first.
+	players := testPlayers(MaxLeaguePlayers)
+	standings := LeagueStandings(players, nil, nil)
+
+	last := players[len(players)-1].Name
+	fixtures := make([]Fixture, 0)
+	for _, p := range players[:len(players)-1] {
+		fixtures = append(fixtures, Fixture{Round: 1, GameName: "chess", Home: p.Name, Away: last})
+	}
+
+	done := make(chan [][2]string)
+	go func() {
+		done <- swissPairs(standings, fixtures)
+	}()
+
+	select {
+	case pairs := <-done:
+		if len(pairs) != MaxLeaguePlayers/2 {
+			t.Errorf("got %d pairs, want %d", len(pairs), MaxLeaguePlayers/2)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pairing did not finish")
+	}
+}
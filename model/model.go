@@ -2,16 +2,25 @@ package model
 
 // Player ...
 type Player struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+	ID   int    `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
 }
 
 // Game ...
 type Game struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+	ID   int    `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+	// Matches must be confirmed by opponent
+	Confirm bool `db:"confirm" json:"confirm"`
 }
 
+// Match statuses, only confirmed matches count in stats
+const (
+	MatchConfirmed = "confirmed"
+	MatchPending   = "pending"
+	MatchRejected  = "rejected"
+)
+
 // Match ...
 type Match struct {
 	ID       int    `json:"id"`
@@ -21,6 +30,49 @@ type Match struct {
 	IsTie    bool   `json:"isTie"`
 	Added    string `json:"added"`
 	Comment  string `json:"comment"`
-	// WinnerScore int    `json:"winnerScore,omitempty"`
-	// LoserScore  int    `json:"loserScore,omitempty"`
+	Status   string `json:"status,omitempty"`
+	// Player who recorded the match, if known
+	ReportedBy string `json:"reportedBy,omitempty"`
+	// Scores are optional
+	WinnerScore *int `json:"winnerScore,omitempty"`
+	LoserScore  *int `json:"loserScore,omitempty"`
+}
+
+// Import is matches saved at once, with players and games they need
+type Import struct {
+	Players []string `json:"players"`
+	Games   []string `json:"games"`
+	Matches []Match  `json:"-"`
+}
+
+// Alias maps name used elsewhere, like chess site handle, to player
+type Alias struct {
+	Alias      string `json:"alias"`
+	PlayerName string `json:"playerName"`
+}
+
+// MatchChange is one changed field in match's history
+type MatchChange struct {
+	ID        int    `json:"id"`
+	MatchID   int    `json:"matchId"`
+	ChangedBy string `json:"changedBy"`
+	ChangedAt string `json:"changedAt"`
+	Field     string `json:"field"`
+	OldValue  string `json:"oldValue"`
+	NewValue  string `json:"newValue"`
+}
+
+// Kinds of deletable rows
+const (
+	KindPlayer = "player"
+	KindGame   = "game"
+	KindMatch  = "match"
+)
+
+// Deleted is row in trash
+type Deleted struct {
+	Kind      string `json:"kind"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	DeletedAt string `json:"deletedAt"`
 }
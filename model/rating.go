@@ -0,0 +1,75 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// Elo rating settings
+const (
+	EloStart = 1500
+	EloK     = 32
+)
+
+// Rating is player's Elo rating in game
+type Rating struct {
+	GameName string  `json:"gameName"`
+	Player   string  `json:"player"`
+	Rating   float64 `json:"rating"`
+	Games    int     `json:"games"`
+}
+
+// Ratings replays matches from oldest to newest and returns every player's
+// rating in every game they played, ordered by game and best first
+func Ratings(matches []Match) []Rating {
+	sorted := make([]Match, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Added != sorted[j].Added {
+			return sorted[i].Added < sorted[j].Added
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	ratings := make(map[[2]string]*Rating)
+	get := func(game, player string) *Rating {
+		k := [2]string{game, player}
+		if ratings[k] == nil {
+			ratings[k] = &Rating{GameName: game, Player: player, Rating: EloStart}
+		}
+		return ratings[k]
+	}
+
+	for _, m := range sorted {
+		winner := get(m.GameName, m.Winner)
+		loser := get(m.GameName, m.Loser)
+
+		score := 1.0
+		if m.IsTie {
+			score = 0.5
+		}
+
+		expected := 1 / (1 + math.Pow(10, (loser.Rating-winner.Rating)/400))
+		change := EloK * (score - expected)
+
+		winner.Rating += change
+		loser.Rating -= change
+		winner.Games++
+		loser.Games++
+	}
+
+	list := make([]Rating, 0, len(ratings))
+	for _, r := range ratings {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].GameName != list[j].GameName {
+			return list[i].GameName < list[j].GameName
+		}
+		if list[i].Rating != list[j].Rating {
+			return list[i].Rating > list[j].Rating
+		}
+		return list[i].Player < list[j].Player
+	})
+	return list
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// DateFormat is format of dates in forms and share links
+const DateFormat = "2006-01-02"
+
+// ShareLink gives read-only access to results without credentials. Its
+// token carries filter and expiry, link itself is kept so it can be revoked.
+type ShareLink struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	GameName string `json:"gameName"`
+	Player1  string `json:"player1"`
+	Player2  string `json:"player2"`
+	// Dates of first and last day included, empty is open
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Expires   string `json:"expires"`
+	CreatedBy string `json:"createdBy"`
+	Created   string `json:"created"`
+	Revoked   bool   `json:"revoked"`
+}
+
+// Filter returns filter selecting link's matches
+func (l ShareLink) Filter() (Filter, error) {
+	f := Filter{
+		GameName: l.GameName,
+		Player1:  l.Player1,
+		Player2:  l.Player2,
+	}
+
+	if l.From != "" {
+		from, err := time.Parse(DateFormat, l.From)
+		if err != nil {
+			return f, err
+		}
+		f.From = from
+	}
+
+	if l.To != "" {
+		to, err := time.Parse(DateFormat, l.To)
+		if err != nil {
+			return f, err
+		}
+		// Last day is included
+		f.To = to.AddDate(0, 0, 1)
+	}
+
+	return f, nil
+}
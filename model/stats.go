@@ -107,13 +107,34 @@ func StatsFromMatches(matches []Match) (SortedStats, error) {
 
 	players.CalculateComputed()
 
-	// return SortedStats
-	ss := make(SortedStats, 0, len(players))
+	return players.Sorted(), nil
+}
+
+// Sorted returns stats as SortedStats, call sort.Sort to actually sort them
+func (sm StatsMap) Sorted() SortedStats {
+	ss := make(SortedStats, 0, len(sm))
 
-	for key, p := range players {
+	for key, p := range sm {
 		p.Name = key
 		ss = append(ss, p)
 	}
 
-	return ss, nil
+	return ss
+}
+
+// Leader returns player with best win percentage, more games and then name
+// decide ties. Empty if there are no stats.
+func (ss SortedStats) Leader() string {
+	var best *Stats
+	for _, s := range ss {
+		if best == nil || s.WinPercentage > best.WinPercentage ||
+			(s.WinPercentage == best.WinPercentage && (s.Games > best.Games ||
+				(s.Games == best.Games && s.Name < best.Name))) {
+			best = s
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Name
 }
@@ -0,0 +1,70 @@
+package model
+
+import "strings"
+
+// Webhook events
+const (
+	EventMatchCreated  = "match.created"
+	EventMatchDeleted  = "match.deleted"
+	EventMatchUpdated  = "match.updated"
+	EventPlayerCreated = "player.created"
+	EventLeaderChanged = "leader.changed"
+	// Sent only when admin tests webhook
+	EventPing = "ping"
+)
+
+// Events webhooks can subscribe to
+var Events = []string{EventMatchCreated, EventMatchDeleted, EventMatchUpdated, EventPlayerCreated, EventLeaderChanged}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook posts events it subscribed to to URL, signed with Secret
+type Webhook struct {
+	ID     int    `json:"id"`
+	URL    string `json:"url"`
+	Secret string `json:"-"`
+	// Empty means all events
+	Events  []string `json:"events"`
+	Created string   `json:"created"`
+}
+
+// Wants tells if webhook subscribed to event, everyone gets ping
+func (w Webhook) Wants(event string) bool {
+	if event == EventPing || len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// EventList is events comma separated, as they are stored
+func (w Webhook) EventList() string {
+	return strings.Join(w.Events, ",")
+}
+
+// Delivery is one event queued to one webhook. Payload is sent as is, so
+// retries have same body and signature.
+type Delivery struct {
+	ID          int    `json:"id"`
+	WebhookID   int    `json:"webhookId"`
+	URL         string `json:"url"`
+	Secret      string `json:"-"`
+	Event       string `json:"event"`
+	Payload     string `json:"payload"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	NextAttempt string `json:"nextAttempt"`
+	// HTTP status of last attempt, 0 if request failed
+	LastStatus int    `json:"lastStatus"`
+	LastError  string `json:"lastError"`
+	Created    string `json:"created"`
+}
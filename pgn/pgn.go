@@ -0,0 +1,153 @@
+// Package pgn reads and writes tag pairs of Portable Game Notation files.
+// Moves are skipped when reading and not written.
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Results
+const (
+	WhiteWins  = "1-0"
+	BlackWins  = "0-1"
+	Draw       = "1/2-1/2"
+	Unfinished = "*"
+)
+
+// Seven Tag Roster, written first and in this order
+var roster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// Game is one game's tag pairs
+type Game struct {
+	Tags map[string]string
+	// Line where game's first tag is
+	Line int
+}
+
+// Tag returns tag's value or empty string
+func (g Game) Tag(name string) string {
+	return g.Tags[name]
+}
+
+// Read returns games from PGN. Anything that isn't a tag pair is treated as
+// movetext, which ends the game's tag section.
+func Read(r io.Reader) ([]Game, error) {
+	games := make([]Game, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var current *Game
+	inMoves := false
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if text == "" || strings.HasPrefix(text, "%") {
+			continue
+		}
+
+		if !strings.HasPrefix(text, "[") {
+			inMoves = current != nil
+			continue
+		}
+
+		if current == nil || inMoves {
+			games = append(games, Game{Tags: make(map[string]string), Line: line})
+			current = &games[len(games)-1]
+			inMoves = false
+		}
+
+		name, value, err := parseTag(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		current.Tags[name] = value
+	}
+
+	return games, scanner.Err()
+}
+
+// Write writes game's tags and result as movetext, roster tags first
+func Write(w io.Writer, g Game) error {
+	written := make(map[string]bool)
+
+	for _, name := range roster {
+		value, ok := g.Tags[name]
+		if !ok {
+			value = "?"
+			if name == "Result" {
+				value = Unfinished
+			}
+		}
+		written[name] = true
+
+		_, err := fmt.Fprintf(w, "[%s \"%s\"]\n", name, escape(value))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rest in alphabetical order
+	names := make([]string, 0, len(g.Tags))
+	for name := range g.Tags {
+		if !written[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err := fmt.Fprintf(w, "[%s \"%s\"]\n", name, escape(g.Tags[name]))
+		if err != nil {
+			return err
+		}
+	}
+
+	result, ok := g.Tags["Result"]
+	if !ok {
+		result = Unfinished
+	}
+
+	_, err := fmt.Fprintf(w, "\n%s\n\n", result)
+	return err
+}
+
+// parseTag parses [Name "Value"]
+func parseTag(text string) (string, string, error) {
+	if !strings.HasSuffix(text, "]") {
+		return "", "", fmt.Errorf("bad tag %s", text)
+	}
+	text = strings.TrimSpace(text[1 : len(text)-1])
+
+	space := strings.IndexAny(text, " \t")
+	if space < 1 {
+		return "", "", fmt.Errorf("bad tag [%s]", text)
+	}
+
+	name := text[:space]
+	quoted := strings.TrimSpace(text[space:])
+	if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+		return "", "", fmt.Errorf("bad tag value in [%s]", text)
+	}
+
+	return name, unescape(quoted[1 : len(quoted)-1]), nil
+}
+
+func escape(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	return strings.Replace(value, `"`, `\"`, -1)
+}
+
+func unescape(value string) string {
+	value = strings.Replace(value, `\"`, `"`, -1)
+	return strings.Replace(value, `\\`, `\`, -1)
+}
@@ -0,0 +1,160 @@
+package pgn
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		games []Game
+	}{
+		{
+			name:  "empty",
+			input: "",
+			games: []Game{},
+		},
+		{
+			name:  "one game",
+			input: "[White \"Alice\"]\n[Black \"Bob\"]\n[Result \"1-0\"]\n\n1. e4 e5 1-0\n",
+			games: []Game{
+				{Tags: map[string]string{"White": "Alice", "Black": "Bob", "Result": "1-0"}, Line: 1},
+			},
+		},
+		{
+			name:  "two games without blank lines",
+			input: "[White \"Alice\"]\n1. e4 *\n[White \"Bob\"]\n[Result \"*\"]\n*\n",
+			games: []Game{
+				{Tags: map[string]string{"White": "Alice"}, Line: 1},
+				{Tags: map[string]string{"White": "Bob", "Result": "*"}, Line: 3},
+			},
+		},
+		{
+			name:  "byte order mark, comments and spaces",
+			input: "\ufeff% exported\n\n  [Event  \"Club night\"]  \n\n[Round\t\"2\"]\n1/2-1/2\n",
+			games: []Game{
+				{Tags: map[string]string{"Event": "Club night", "Round": "2"}, Line: 3},
+			},
+		},
+		{
+			name:  "escaped value",
+			input: `[Annotator "Say \"hi\" C:\\pgn"]` + "\n",
+			games: []Game{
+				{Tags: map[string]string{"Annotator": `Say "hi" C:\pgn`}, Line: 1},
+			},
+		},
+		{
+			name:  "movetext before tags",
+			input: "1. e4 e5\n[White \"Alice\"]\n",
+			games: []Game{
+				{Tags: map[string]string{"White": "Alice"}, Line: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, err := Read(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(games, tt.games) {
+				t.Errorf("got %+v, want %+v", games, tt.games)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"unclosed tag", "[White \"Alice\"\n", "line 1: bad tag"},
+		{"no value", "[White]\n", "line 1: bad tag [White]"},
+		{"unquoted value", "[White Alice]\n", "line 1: bad tag value"},
+		{"error line", "[White \"Alice\"]\n\n[Black \"Bob]\n", "line 3: bad tag value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("got error %q, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want string
+	}{
+		{
+			name: "missing roster tags",
+			tags: map[string]string{"White": "Alice", "Black": "Bob"},
+			want: "[Event \"?\"]\n[Site \"?\"]\n[Date \"?\"]\n[Round \"?\"]\n[White \"Alice\"]\n[Black \"Bob\"]\n[Result \"*\"]\n\n*\n\n",
+		},
+		{
+			name: "extra tags sorted after roster",
+			tags: map[string]string{"Result": "0-1", "TimeControl": "600", "Annotator": "jumbo"},
+			want: "[Event \"?\"]\n[Site \"?\"]\n[Date \"?\"]\n[Round \"?\"]\n[White \"?\"]\n[Black \"?\"]\n[Result \"0-1\"]\n[Annotator \"jumbo\"]\n[TimeControl \"600\"]\n\n0-1\n\n",
+		},
+		{
+			name: "escaped value",
+			tags: map[string]string{"Event": `"Open" \ 2020`, "Result": "1/2-1/2"},
+			want: "[Event \"\\\"Open\\\" \\\\ 2020\"]\n[Site \"?\"]\n[Date \"?\"]\n[Round \"?\"]\n[White \"?\"]\n[Black \"?\"]\n[Result \"1/2-1/2\"]\n\n1/2-1/2\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, Game{Tags: tt.tags})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	values := []string{"Alice", `a "quoted" name`, `C:\games\`, `\"`, `\\"`, ""}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, Game{Tags: map[string]string{"White": value, "Result": WhiteWins}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			games, err := Read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(games) != 1 {
+				t.Fatalf("got %d games, want 1", len(games))
+			}
+			if got := games[0].Tag("White"); got != value {
+				t.Errorf("got %q, want %q", got, value)
+			}
+			if got := games[0].Tag("Result"); got != WhiteWins {
+				t.Errorf("got result %q, want %q", got, WhiteWins)
+			}
+		})
+	}
+}
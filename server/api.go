@@ -1,12 +1,16 @@
 package server
 
 import (
-	"log"
+	"crypto/subtle"
+	"errors"
+	"html/template"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/tuommii/jumbo/chart"
+	"github.com/tuommii/jumbo/database"
 	"github.com/tuommii/jumbo/model"
 )
 
@@ -15,6 +19,7 @@ func (s *Server) apiHome(w http.ResponseWriter, r *http.Request) {
 	session, _ := s.cookies.Get(r, "mysession")
 
 	flashes := session.Flashes()
+	undoFlashes := session.Flashes(undoFlashKey)
 	session.Save(r, w)
 
 	var msg string
@@ -24,37 +29,74 @@ func (s *Server) apiHome(w http.ResponseWriter, r *http.Request) {
 		msg = flash
 	}
 
-	players, err := s.db.GetPlayers()
+	var undo *model.Deleted
+	if len(undoFlashes) > 0 {
+		undo = parseUndo(undoFlashes[0].(string))
+	}
+
+	players, err := s.db.GetPlayers(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	games, err := s.db.GetGames(r.Context())
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	games, err := s.db.GetGames()
+	challenges, err := s.db.GetOpenChallenges(r.Context())
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Pending matches are shown only to players involved
+	me := currentPlayer(session)
+	pending := make([]model.Match, 0)
+	if me != "" {
+		pending, err = s.db.GetPendingMatches(r.Context(), me)
+		if err != nil {
+			logError(r, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Calendar entries link here with match prefilled
+	prefill := model.Match{
+		GameName: r.FormValue("gameName"),
+		Winner:   r.FormValue("winner"),
+		Loser:    r.FormValue("loser"),
+	}
+
 	response := struct {
-		Players []model.Player
-		Games   []model.Game
-		Message string
+		Players    []model.Player
+		Games      []model.Game
+		Challenges []model.Challenge
+		Pending    []model.Match
+		Me         string
+		Message    string
+		Undo       *model.Deleted
+		Prefill    model.Match
 	}{
 		players,
 		games,
+		challenges,
+		pending,
+		me,
 		msg,
+		undo,
+		prefill,
 	}
 	s.templates["home.html"].ExecuteTemplate(w, "base", response)
 }
 
 func (s *Server) apiCreateMatch(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
 	session, _ := s.cookies.Get(r, "mysession")
 
 	gameName := r.FormValue("gameName")
@@ -84,18 +126,36 @@ func (s *Server) apiCreateMatch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	match := model.Match{
-		GameName: gameName,
-		Winner:   winner,
-		Loser:    loser,
-		Comment:  comment,
-		IsTie:    isTie,
+		GameName:   gameName,
+		Winner:     winner,
+		Loser:      loser,
+		Comment:    comment,
+		IsTie:      isTie,
+		ReportedBy: currentPlayer(session),
 	}
 
-	_, err := s.db.CreateMatch(match)
+	id, err := s.store(r).CreateMatch(r.Context(), match)
+	if err == database.ErrNotIdentified {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	match, err = s.db.GetMatch(r.Context(), int(id))
 	if err != nil {
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.matchChanged(r.Context(), model.EventMatchCreated, match)
+
+	if wantsJSON(r) {
+		writeJSON(w, r, http.StatusCreated, match)
+		return
+	}
 
 	session.AddFlash("Added new game: " + gameName + " | " + winner + " - " + loser)
 	session.Save(r, w)
@@ -103,34 +163,33 @@ func (s *Server) apiCreateMatch(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) apiCreatePlayer(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	playerName := r.FormValue("playerName")
 
-	_, err := s.db.CreatePlayer(playerName)
+	id, err := s.store(r).CreatePlayer(r.Context(), playerName)
+	if errors.Is(err, database.ErrInTrash) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.notify(r.Context(), model.EventPlayerCreated, model.Player{ID: int(id), Name: playerName})
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 func (s *Server) apiCreateGame(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	gameName := r.FormValue("gameName")
 
-	_, err := s.db.CreateGame(gameName)
+	_, err := s.store(r).CreateGame(r.Context(), gameName)
+	if errors.Is(err, database.ErrInTrash) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -139,99 +198,91 @@ func (s *Server) apiCreateGame(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) apiDeleteMatch(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	id, err := strconv.Atoi(r.FormValue("id"))
-	log.Println("ID:", id)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	num, err := s.db.DeleteMatch(id)
-	log.Println("NUM", num)
+	// Deleted match can't be read anymore, event needs its game
+	match, _ := s.db.GetMatch(r.Context(), id)
+
+	num, err := s.store(r).DeleteMatch(r.Context(), id)
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if num > 0 {
+		s.matchChanged(r.Context(), model.EventMatchDeleted, match)
+		s.flashUndo(w, r, model.KindMatch, id, "Deleted game "+strconv.Itoa(id))
+	}
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 func (s *Server) apiDeleteGame(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	gameName := r.FormValue("gameName")
+
+	games, err := s.db.GetGames(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	gameName := r.FormValue("gameName")
-
-	_, err := s.db.DeleteGame(gameName)
+	_, err = s.store(r).DeleteGame(r.Context(), gameName)
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	for _, g := range games {
+		if g.Name == gameName {
+			s.flashUndo(w, r, model.KindGame, g.ID, "Deleted game type "+gameName)
+		}
+	}
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 func (s *Server) apiDeletePlayer(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	playerName := r.FormValue("playerName")
 
-	_, err := s.db.DeletePlayer(playerName)
-
+	players, err := s.db.GetPlayers(r.Context())
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
-}
-
-func (s *Server) apiSearch(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	gameName := r.FormValue("gameName")
-	p1 := r.FormValue("player1")
-	p2 := r.FormValue("player2")
-
-	ld, err := strconv.Atoi(r.FormValue("limitDays"))
+	_, err = s.store(r).DeletePlayer(r.Context(), playerName)
 	if err != nil {
-		ld = 0
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	lg, err := strconv.Atoi(r.FormValue("limitGames"))
-	if err != nil {
-		lg = 0
+	for _, p := range players {
+		if p.Name == playerName {
+			s.flashUndo(w, r, model.KindPlayer, p.ID, "Deleted player "+playerName)
+		}
 	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
 
-	// Create filter based on form values
-	f := model.Filter{
-		GameName:   gameName,
-		Player1:    p1,
-		Player2:    p2,
-		LimitDays:  ld,
-		LimitGames: lg,
-	}
+func (s *Server) apiSearch(w http.ResponseWriter, r *http.Request) {
+	f := filterFromRequest(r)
+	s.renderResults(w, r, f, template.URL(r.PostForm.Encode()), nil)
+}
 
-	matches, err := s.db.GetMatches(f)
+// renderResults shows stats, charts and latest games of filter. Shared
+// page is read-only, it has no edit, export or feed links.
+func (s *Server) renderResults(w http.ResponseWriter, r *http.Request, f model.Filter, query template.URL, shared *model.ShareLink) {
+	matches, err := s.db.GetMatches(r.Context(), f)
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, "Search error", http.StatusInternalServerError)
 		return
 	}
@@ -239,31 +290,77 @@ func (s *Server) apiSearch(w http.ResponseWriter, r *http.Request) {
 	// Calculate stats form matches
 	stats, err := model.StatsFromMatches(matches)
 	if err != nil {
-		log.Println(err)
+		logError(r, err)
 		http.Error(w, "Stats error", http.StatusInternalServerError)
 		return
 	}
 
 	sort.Sort(stats)
 
+	// Charts are drawn here, page needs no scripts for them
+	charts := make([]template.HTML, 0)
+	if len(matches) > 0 {
+		charts = append(charts,
+			template.HTML(chart.WinLoss(matches)),
+			template.HTML(chart.CumulativeWins(matches)),
+			template.HTML(chart.GamesPerWeek(matches)),
+		)
+		if f.Player1 != "" && f.Player2 != "" {
+			charts = append(charts, template.HTML(chart.HeadToHead(matches, f.Player1, f.Player2)))
+		}
+	}
+
 	// Anonyme struct
 	data := struct {
 		Stats    model.SortedStats
 		Matches  []model.Match
 		GameName string
+		Charts   []template.HTML
+		// Same filter for export links
+		Query  template.URL
+		Shared *model.ShareLink
 	}{
 		stats,
 		matches,
 		f.GameName,
+		charts,
+		query,
+		shared,
 	}
 	// json.NewEncoder(w).Encode(data)
 	// http.Redirect(w, r, "/api/results", http.StatusSeeOther)
 	s.templates["results.html"].ExecuteTemplate(w, "base", data)
 }
 
-// Auth middleware
-func auth(next http.HandlerFunc) http.HandlerFunc {
+// filterFromRequest creates filter based on form values
+func filterFromRequest(r *http.Request) model.Filter {
+	ld, err := strconv.Atoi(r.FormValue("limitDays"))
+	if err != nil {
+		ld = 0
+	}
+
+	lg, err := strconv.Atoi(r.FormValue("limitGames"))
+	if err != nil {
+		lg = 0
+	}
+
+	return model.Filter{
+		GameName:   r.FormValue("gameName"),
+		Player1:    r.FormValue("player1"),
+		Player2:    r.FormValue("player2"),
+		LimitDays:  ld,
+		LimitGames: lg,
+	}
+}
+
+// Auth middleware, accepts basic auth or API token
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if s.hasToken(r) {
+			next(w, r)
+			return
+		}
+
 		// Prompt credentials in browser
 		w.Header().Set("WWW-Authenticate", `Basic realm="Jumbo - Track Stats"`)
 
@@ -277,6 +374,53 @@ func auth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// adminAuth is auth with admin credentials
+func (s *Server) adminAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Prompt credentials in browser
+		w.Header().Set("WWW-Authenticate", `Basic realm="Jumbo - Admin"`)
+
+		user, pswd, _ := r.BasicAuth()
+		if user != s.adminUsername || pswd != s.adminPassword {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// actor returns who is making the request, chosen player or basic auth user
+func (s *Server) actor(r *http.Request) string {
+	session, _ := s.cookies.Get(r, "mysession")
+	if player := currentPlayer(session); player != "" {
+		return player
+	}
+
+	if s.hasToken(r) {
+		return "token"
+	}
+
+	user, _, _ := r.BasicAuth()
+	return user
+}
+
+// hasToken tells if request has valid bearer token
+func (s *Server) hasToken(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+	token := []byte(strings.TrimPrefix(header, "Bearer "))
+
+	for _, t := range s.apiTokens {
+		if subtle.ConstantTimeCompare(token, []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
 // Favicon server favicon
 func (s *Server) favicon(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "favicon.png")
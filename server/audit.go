@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/csv"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/model"
+)
+
+// Entries shown in audit viewer when limit is not given
+const defaultAuditLimit = 200
+
+// store returns database that records writes made in request to audit log
+func (s *Server) store(r *http.Request) database.Database {
+	return database.WithAudit(s.db, database.Actor{
+		Name:       s.actor(r),
+		RemoteAddr: r.RemoteAddr,
+		RequestID:  requestID(r),
+	})
+}
+
+func (s *Server) adminAudit(w http.ResponseWriter, r *http.Request) {
+	entityID, _ := strconv.Atoi(r.FormValue("entityId"))
+	limitDays, _ := strconv.Atoi(r.FormValue("limitDays"))
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil {
+		limit = defaultAuditLimit
+	}
+
+	f := model.AuditFilter{
+		Actor:     r.FormValue("actor"),
+		Action:    r.FormValue("action"),
+		Entity:    r.FormValue("entity"),
+		EntityID:  entityID,
+		LimitDays: limitDays,
+		Limit:     limit,
+	}
+
+	// Export has everything matching filter unless limited explicitly
+	csvExport := r.FormValue("format") == "csv"
+	if csvExport && r.FormValue("limit") == "" {
+		f.Limit = 0
+	}
+
+	entries, err := s.db.GetAuditEntries(r.Context(), f)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if csvExport {
+		writeAuditCSV(w, r, entries)
+		return
+	}
+
+	data := struct {
+		Filter  model.AuditFilter
+		Entries []model.AuditEntry
+		Query   template.URL
+	}{
+		f,
+		entries,
+		template.URL(r.URL.Query().Encode()),
+	}
+	s.templates["audit.html"].ExecuteTemplate(w, "base", data)
+}
+
+func writeAuditCSV(w http.ResponseWriter, r *http.Request, entries []model.AuditEntry) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="jumbo-audit.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "created", "actor", "remote_addr", "request_id", "action", "entity", "entity_id", "before", "after"})
+
+	for _, e := range entries {
+		cw.Write([]string{
+			strconv.Itoa(e.ID),
+			e.Created,
+			e.Actor,
+			e.RemoteAddr,
+			e.RequestID,
+			e.Action,
+			e.Entity,
+			strconv.Itoa(e.EntityID),
+			e.Before,
+			e.After,
+		})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		logError(r, err)
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/logging"
+)
+
+// backup writes backup to backup dir on start and every interval after it,
+// old ones are rotated
+func (s *Server) backup(ctx context.Context, interval time.Duration) {
+	logger := logging.FromContext(ctx).With("job", "backup")
+	ctx = logging.WithLogger(ctx, logger)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		path, err := database.BackupTo(ctx, s.db, s.backupDir, s.backupKeep)
+		if err != nil {
+			logger.Error("backup failed", "err", err)
+		} else {
+			logger.Info("backup done", "path", path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// adminBackup downloads snapshot taken now
+func (s *Server) adminBackup(w http.ResponseWriter, r *http.Request) {
+	noWriteTimeout(w, r)
+
+	dir, err := ioutil.TempDir("", "jumbo-backup")
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	name := "jumbo-" + time.Now().UTC().Format("20060102-150405") + ".db"
+	path := filepath.Join(dir, name)
+
+	err = s.db.Backup(r.Context(), path)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/chart"
+	"github.com/tuommii/jumbo/model"
+)
+
+// Badge metrics
+const (
+	metricWins    = "win"
+	metricRecord  = "record"
+	metricRating  = "rating"
+	metricStreak  = "streak"
+	metricLeader  = "leader"
+	metricMatches = "matches"
+)
+
+// Browsers and wikis may reuse badge this long without asking
+const badgeMaxAge = 5 * time.Minute
+
+// apiBadge draws badge of player or game. Matches are selected like in
+// search: gameName, player, limitDays and limitGames.
+func (s *Server) apiBadge(w http.ResponseWriter, r *http.Request) {
+	f := filterFromRequest(r)
+	f.Player1 = r.FormValue("player")
+	f.Player2 = ""
+
+	metric := r.FormValue("metric")
+	if metric == "" {
+		metric = metricWins
+		if f.Player1 == "" {
+			metric = metricLeader
+		}
+	}
+
+	label, value, color, err := s.badgeValue(r, f, metric)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if l := r.FormValue("label"); l != "" {
+		label = l
+	}
+
+	svg := []byte(chart.Badge(label, value, color))
+	sum := sha1.Sum(svg)
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(badgeMaxAge.Seconds())))
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	// Answers If-None-Match with 304 when badge hasn't changed
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(svg))
+}
+
+// badgeValue returns label, value and color of badge. Unknown metrics and
+// players without matches get gray badge, not error.
+func (s *Server) badgeValue(r *http.Request, f model.Filter, metric string) (string, string, string, error) {
+	player := f.Player1
+	label := player
+	if label == "" {
+		label = f.GameName
+	}
+	if label == "" {
+		label = "jumbo"
+	}
+
+	// Rating depends on opponents' ratings too
+	if metric == metricRating {
+		f.Player1 = ""
+	}
+
+	matches, err := s.db.GetMatches(r.Context(), f)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	// Streaks are counted oldest first, matches come newest first
+	ordered := make([]model.Match, len(matches))
+	for i, m := range matches {
+		ordered[len(matches)-1-i] = m
+	}
+
+	stats, err := model.StatsFromMatches(ordered)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	// Search matches names case insensitively, exact name wins
+	var st *model.Stats
+	for _, x := range stats {
+		if player != "" && (x.Name == player || (st == nil && strings.EqualFold(x.Name, player))) {
+			st = x
+		}
+	}
+
+	switch {
+	case player == "" && metric == metricLeader:
+		leader := stats.Leader()
+		if leader == "" {
+			return label, "no matches", chart.BadgeGray, nil
+		}
+		for _, x := range stats {
+			if x.Name == leader {
+				return label, leader + " " + FormatPercentage(x.WinPercentage) + "%", chart.BadgeBlue, nil
+			}
+		}
+	case player == "" && metric == metricMatches:
+		return label, strconv.Itoa(len(matches)) + " matches", chart.BadgeBlue, nil
+	case metric == metricRating:
+		return ratingBadge(label, player, model.Ratings(matches))
+	case player == "":
+	case st == nil:
+		return label, "no matches", chart.BadgeGray, nil
+	case metric == metricWins:
+		return label, FormatPercentage(st.WinPercentage) + "% wins", winColor(st.WinPercentage), nil
+	case metric == metricRecord:
+		value := fmt.Sprintf("%d-%d", st.Wins, st.Losses)
+		if st.Ties > 0 {
+			value = fmt.Sprintf("%d-%d-%d", st.Wins, st.Ties, st.Losses)
+		}
+		return label, value, winColor(st.WinPercentage), nil
+	case metric == metricStreak:
+		color := chart.BadgeGray
+		if st.CurrentWinStreak > 0 {
+			color = chart.BadgeGreen
+		}
+		return label, fmt.Sprintf("%d win streak", st.CurrentWinStreak), color, nil
+	}
+	return label, "unknown metric", chart.BadgeGray, nil
+}
+
+// ratingBadge shows player's rating in game they played most, or best
+// rated player if there is no player
+func ratingBadge(label string, player string, ratings []model.Rating) (string, string, string, error) {
+	var best *model.Rating
+	for i, rt := range ratings {
+		if player == "" && (best == nil || rt.Rating > best.Rating) {
+			best = &ratings[i]
+		}
+		if player != "" && strings.EqualFold(rt.Player, player) && (best == nil || rt.Games > best.Games) {
+			best = &ratings[i]
+		}
+	}
+	if best == nil {
+		return label, "no rating", chart.BadgeGray, nil
+	}
+
+	value := fmt.Sprintf("%.0f Elo", best.Rating)
+	if player == "" {
+		value = best.Player + " " + value
+	} else if label != best.GameName {
+		value += " " + best.GameName
+	}
+	return label, value, chart.BadgeBlue, nil
+}
+
+func winColor(p float64) string {
+	switch {
+	case p >= 0.6:
+		return chart.BadgeGreen
+	case p >= 0.4:
+		return chart.BadgeYellow
+	}
+	return chart.BadgeRed
+}
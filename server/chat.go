@@ -0,0 +1,370 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/model"
+)
+
+const (
+	// Slack rejects requests older than this to stop replays
+	chatMaxAge = 5 * time.Minute
+	// Slack's form is small, this is plenty
+	chatMaxBody = 64 << 10
+)
+
+// Who sees reply
+const (
+	chatInChannel = "in_channel"
+	chatEphemeral = "ephemeral"
+)
+
+const chatHelp = "Usage:\n" +
+	"`/jumbo iam <player> <password>` tell who you are\n" +
+	"`/jumbo won <game> vs <player>` record your win, also `lost` and `tied`\n" +
+	"`/jumbo stats <game>` standings\n" +
+	"`/jumbo h2h <player> <player> [game]` head to head"
+
+// chatReply is response both Slack and Mattermost understand
+type chatReply struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// chatRequest is slash command with sender and known names to resolve its
+// arguments against
+type chatRequest struct {
+	r        *http.Request
+	userID   string
+	userName string
+	args     []string
+	players  []model.Player
+	games    []model.Game
+	aliases  []model.Alias
+}
+
+// apiChatCommand answers Slack and Mattermost slash commands
+func (s *Server) apiChatCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, chatMaxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Form is parsed from body that was read for signature
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	err = r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !s.chatVerified(r, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	c := &chatRequest{
+		r:        r,
+		userID:   r.PostFormValue("user_id"),
+		userName: r.PostFormValue("user_name"),
+		args:     strings.Fields(r.PostFormValue("text")),
+	}
+
+	reply, err := s.chatCommand(c)
+	if err != nil {
+		logError(r, err)
+		reply = chatReply{chatEphemeral, "Something went wrong: " + err.Error()}
+	}
+	writeJSON(w, r, http.StatusOK, reply)
+}
+
+// chatVerified checks Slack's signature, or Mattermost's token
+func (s *Server) chatVerified(r *http.Request, body []byte) bool {
+	if signature := r.Header.Get("X-Slack-Signature"); signature != "" && s.slackSecret != "" {
+		timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return false
+		}
+		age := time.Since(time.Unix(sec, 0))
+		if age > chatMaxAge || age < -chatMaxAge {
+			return false
+		}
+
+		mac := hmac.New(sha256.New, []byte(s.slackSecret))
+		fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+		expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(expected), []byte(signature))
+	}
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		return false
+	}
+	for _, t := range s.mattermostTokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) chatCommand(c *chatRequest) (chatReply, error) {
+	if len(c.args) == 0 {
+		return chatReply{chatEphemeral, chatHelp}, nil
+	}
+
+	ctx := c.r.Context()
+	var err error
+
+	c.players, err = s.db.GetPlayers(ctx)
+	if err != nil {
+		return chatReply{}, err
+	}
+	c.games, err = s.db.GetGames(ctx)
+	if err != nil {
+		return chatReply{}, err
+	}
+	c.aliases, err = s.db.GetAliases(ctx)
+	if err != nil {
+		return chatReply{}, err
+	}
+
+	switch strings.ToLower(c.args[0]) {
+	case "iam":
+		return s.chatIAm(ctx, c)
+	case "won", "lost", "tied":
+		return s.chatResult(ctx, c)
+	case "stats":
+		return s.chatStats(ctx, c)
+	case "h2h":
+		return s.chatHeadToHead(ctx, c)
+	}
+	return chatReply{chatEphemeral, chatHelp}, nil
+}
+
+// chatStore returns database that records writes of chat user to audit log
+func (s *Server) chatStore(c *chatRequest) database.Database {
+	return database.WithAudit(s.db, database.Actor{
+		Name:       "chat:" + c.userName,
+		RemoteAddr: c.r.RemoteAddr,
+		RequestID:  requestID(c.r),
+	})
+}
+
+// chatIAm maps sender to player whose password it knows, same password
+// identifies player in web UI. Slash command text isn't shown in channel.
+func (s *Server) chatIAm(ctx context.Context, c *chatRequest) (chatReply, error) {
+	if len(c.args) < 3 {
+		return chatReply{chatEphemeral, "Usage: `/jumbo iam <player> <password>`"}, nil
+	}
+
+	player, ok := c.player(c.args[1])
+	if !ok {
+		return chatReply{chatEphemeral, "No player " + c.args[1]}, nil
+	}
+
+	ok, err := s.db.CheckPlayerPassword(ctx, player, strings.Join(c.args[2:], " "))
+	if err != nil {
+		return chatReply{}, err
+	}
+	if !ok {
+		return chatReply{chatEphemeral, "Wrong password, or " + player + " has none. Admin sets passwords with `jumbo player password`"}, nil
+	}
+
+	_, err = s.chatStore(c).SetChatPlayer(ctx, c.userID, player)
+	if err == database.ErrPlayerClaimed {
+		return chatReply{chatEphemeral, player + " is already claimed by another chat user"}, nil
+	}
+	if err != nil {
+		return chatReply{}, err
+	}
+	return chatReply{chatEphemeral, "You are " + player + " now"}, nil
+}
+
+// chatResult records match between sender and opponent:
+// won|lost|tied <game> [vs] <player>
+func (s *Server) chatResult(ctx context.Context, c *chatRequest) (chatReply, error) {
+	verb := strings.ToLower(c.args[0])
+	args := c.args[1:]
+	if len(args) >= 3 && strings.EqualFold(args[len(args)-2], "vs") {
+		args = append(args[:len(args)-2], args[len(args)-1])
+	}
+	if len(args) < 2 {
+		return chatReply{chatEphemeral, "Usage: `/jumbo " + verb + " <game> vs <player>`"}, nil
+	}
+
+	me, err := s.db.GetChatPlayer(ctx, c.userID)
+	if err != nil {
+		return chatReply{}, err
+	}
+	if me == "" {
+		return chatReply{chatEphemeral, "Tell who you are first: `/jumbo iam <player> <password>`"}, nil
+	}
+
+	gameName, ok := c.game(strings.Join(args[:len(args)-1], " "))
+	if !ok {
+		return chatReply{chatEphemeral, "No game " + strings.Join(args[:len(args)-1], " ")}, nil
+	}
+	opponent, ok := c.player(args[len(args)-1])
+	if !ok {
+		return chatReply{chatEphemeral, "No player " + args[len(args)-1]}, nil
+	}
+	if opponent == me {
+		return chatReply{chatEphemeral, "You can't play against yourself"}, nil
+	}
+
+	match := model.Match{
+		GameName:   gameName,
+		Winner:     me,
+		Loser:      opponent,
+		Comment:    "EMPTY",
+		IsTie:      verb == "tied",
+		ReportedBy: me,
+	}
+	if verb == "lost" {
+		match.Winner, match.Loser = opponent, me
+	}
+
+	id, err := s.chatStore(c).CreateMatch(ctx, match)
+	if err != nil {
+		return chatReply{}, err
+	}
+
+	match, err = s.db.GetMatch(ctx, int(id))
+	if err != nil {
+		return chatReply{}, err
+	}
+	s.matchChanged(c.r.Context(), model.EventMatchCreated, match)
+
+	text := fmt.Sprintf("%s beat %s in %s", match.Winner, match.Loser, gameName)
+	if match.IsTie {
+		text = fmt.Sprintf("%s and %s tied in %s", match.Winner, match.Loser, gameName)
+	}
+	if match.Status == model.MatchPending {
+		text += fmt.Sprintf(", waiting for %s to confirm", opponent)
+	}
+	return chatReply{chatInChannel, text}, nil
+}
+
+func (s *Server) chatStats(ctx context.Context, c *chatRequest) (chatReply, error) {
+	if len(c.args) < 2 {
+		return chatReply{chatEphemeral, "Usage: `/jumbo stats <game>`"}, nil
+	}
+
+	gameName, ok := c.game(strings.Join(c.args[1:], " "))
+	if !ok {
+		return chatReply{chatEphemeral, "No game " + strings.Join(c.args[1:], " ")}, nil
+	}
+
+	text, err := s.chatStatsText(ctx, model.Filter{GameName: gameName}, gameName)
+	if err != nil {
+		return chatReply{}, err
+	}
+	return chatReply{chatInChannel, text}, nil
+}
+
+// chatHeadToHead shows stats of matches between two players:
+// h2h <player> <player> [game]
+func (s *Server) chatHeadToHead(ctx context.Context, c *chatRequest) (chatReply, error) {
+	if len(c.args) < 3 {
+		return chatReply{chatEphemeral, "Usage: `/jumbo h2h <player> <player> [game]`"}, nil
+	}
+
+	f := model.Filter{}
+	var ok bool
+	f.Player1, ok = c.player(c.args[1])
+	if !ok {
+		return chatReply{chatEphemeral, "No player " + c.args[1]}, nil
+	}
+	f.Player2, ok = c.player(c.args[2])
+	if !ok {
+		return chatReply{chatEphemeral, "No player " + c.args[2]}, nil
+	}
+
+	title := f.Player1 + " vs " + f.Player2
+	if len(c.args) > 3 {
+		f.GameName, ok = c.game(strings.Join(c.args[3:], " "))
+		if !ok {
+			return chatReply{chatEphemeral, "No game " + strings.Join(c.args[3:], " ")}, nil
+		}
+		title += " in " + f.GameName
+	}
+
+	text, err := s.chatStatsText(ctx, f, title)
+	if err != nil {
+		return chatReply{}, err
+	}
+	return chatReply{chatInChannel, text}, nil
+}
+
+// chatStatsText formats stats of matches in filter as table in code block
+func (s *Server) chatStatsText(ctx context.Context, f model.Filter, title string) (string, error) {
+	matches, err := s.db.GetMatches(ctx, f)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "No matches for " + title, nil
+	}
+
+	stats, err := model.StatsFromMatches(matches)
+	if err != nil {
+		return "", err
+	}
+	sort.Sort(stats)
+
+	var b strings.Builder
+	b.WriteString("*" + title + "*\n```\n")
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Name\tG\tW\tT\tL\tWin%")
+	for _, st := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s%%\n",
+			st.Name, st.Games, st.Wins, st.Ties, st.Losses, FormatPercentage(st.WinPercentage))
+	}
+	tw.Flush()
+	b.WriteString("```")
+	return b.String(), nil
+}
+
+// player resolves name or alias to player's name, case doesn't matter.
+// Only known names go to queries.
+func (c *chatRequest) player(name string) (string, bool) {
+	name = strings.TrimPrefix(name, "@")
+	for _, p := range c.players {
+		if strings.EqualFold(p.Name, name) {
+			return p.Name, true
+		}
+	}
+	for _, a := range c.aliases {
+		if strings.EqualFold(a.Alias, name) {
+			return a.PlayerName, true
+		}
+	}
+	return "", false
+}
+
+// game resolves name to game's name, case doesn't matter
+func (c *chatRequest) game(name string) (string, bool) {
+	for _, g := range c.games {
+		if strings.EqualFold(g.Name, name) {
+			return g.Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/model"
+)
+
+// slackSign signs body like Slack does
+func slackSign(secret string, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestChatVerified(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-chatMaxAge-time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(chatMaxAge+time.Minute).Unix(), 10)
+	body := "user_id=U1&text=stats+chess"
+
+	tests := []struct {
+		name       string
+		slack      string
+		mattermost []string
+		token      string
+		timestamp  string
+		signature  string
+		want       bool
+	}{
+		{"slack signature", "s3cret", nil, "", now, slackSign("s3cret", now, body), true},
+		{"slack signature with other secret", "s3cret", nil, "", now, slackSign("other", now, body), false},
+		{"slack signature of other time", "s3cret", nil, "", now, slackSign("s3cret", old, body), false},
+		{"slack without timestamp", "s3cret", nil, "", "", slackSign("s3cret", "", body), false},
+		{"slack bad timestamp", "s3cret", nil, "", "yesterday", slackSign("s3cret", "yesterday", body), false},
+		{"slack old timestamp", "s3cret", nil, "", old, slackSign("s3cret", old, body), false},
+		{"slack future timestamp", "s3cret", nil, "", future, slackSign("s3cret", future, body), false},
+		{"slack without signature", "s3cret", nil, "", now, "", false},
+		{"mattermost token", "", []string{"tok1", "tok2"}, "tok2", "", "", true},
+		{"mattermost wrong token", "", []string{"tok1", "tok2"}, "tok3", "", "", false},
+		{"mattermost without token", "", []string{"tok1"}, "", "", "", false},
+		{"slack signature when only mattermost", "", []string{"tok1"}, "", now, slackSign("", now, body), false},
+		{"nothing configured", "", nil, "", now, slackSign("", now, body), false},
+		{"nothing configured with token", "", nil, "tok1", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{slackSecret: tt.slack, mattermostTokens: tt.mattermost}
+
+			b := body
+			if tt.token != "" {
+				b = "token=" + tt.token + "&" + body
+			}
+			r := httptest.NewRequest("POST", "/chat/command", strings.NewReader(b))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.timestamp != "" {
+				r.Header.Set("X-Slack-Request-Timestamp", tt.timestamp)
+			}
+			if tt.signature != "" {
+				r.Header.Set("X-Slack-Signature", tt.signature)
+			}
+
+			if got := s.chatVerified(r, []byte(b)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// chatDB has players with passwords and chat users mapped to them
+type chatDB struct {
+	database.Database
+	passwords map[string]string
+	aliases   []model.Alias
+	users     map[string]string
+}
+
+func (db *chatDB) GetPlayers(ctx context.Context) ([]model.Player, error) {
+	players := make([]model.Player, 0)
+	for name := range db.passwords {
+		players = append(players, model.Player{Name: name})
+	}
+	return players, nil
+}
+
+func (db *chatDB) GetGames(ctx context.Context) ([]model.Game, error) {
+	return nil, nil
+}
+
+func (db *chatDB) GetAliases(ctx context.Context) ([]model.Alias, error) {
+	return db.aliases, nil
+}
+
+func (db *chatDB) CheckPlayerPassword(ctx context.Context, name string, password string) (bool, error) {
+	hash := db.passwords[name]
+	return hash != "" && hash == password, nil
+}
+
+func (db *chatDB) GetChatPlayer(ctx context.Context, userID string) (string, error) {
+	return db.users[userID], nil
+}
+
+func (db *chatDB) SetChatPlayer(ctx context.Context, userID string, player string) (int64, error) {
+	for id, p := range db.users {
+		if p == player && id != userID {
+			return 0, database.ErrPlayerClaimed
+		}
+	}
+	db.users[userID] = player
+	return 1, nil
+}
+
+func (db *chatDB) AddAuditEntry(ctx context.Context, e model.AuditEntry) error {
+	return nil
+}
+
+func TestChatIAm(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		reply  string
+		player string
+	}{
+		{"without password", "iam alice", "Usage: `/jumbo iam <player> <password>`", ""},
+		{"unknown player", "iam dave pw", "No player dave", ""},
+		{"wrong password", "iam alice nope", "Wrong password, or alice has none. Admin sets passwords with `jumbo player password`", ""},
+		{"player without password", "iam carol anything", "Wrong password, or carol has none. Admin sets passwords with `jumbo player password`", ""},
+		{"right password", "iam Alice correct horse", "You are alice now", "alice"},
+		{"alias", "iam @ali correct horse", "You are alice now", "alice"},
+		{"claimed by other", "iam bob b0b", "bob is already claimed by another chat user", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &chatDB{
+				passwords: map[string]string{"alice": "correct horse", "bob": "b0b", "carol": ""},
+				aliases:   []model.Alias{{Alias: "ali", PlayerName: "alice"}},
+				users:     map[string]string{"U2": "bob"},
+			}
+			s := &Server{db: db, mattermostTokens: []string{"tok"}}
+
+			form := url.Values{"token": {"tok"}, "user_id": {"U1"}, "user_name": {"eve"}, "text": {tt.text}}
+			r := httptest.NewRequest("POST", "/chat/command", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.apiChatCommand(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("got status %d: %s", w.Code, w.Body)
+			}
+			reply := chatReply{}
+			err := json.NewDecoder(w.Body).Decode(&reply)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reply.Text != tt.reply || reply.ResponseType != chatEphemeral {
+				t.Errorf("got reply %+v, want %q", reply, tt.reply)
+			}
+			if db.users["U1"] != tt.player {
+				t.Errorf("U1 is %q, want %q", db.users["U1"], tt.player)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/sessions"
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/logging"
+	"github.com/tuommii/jumbo/model"
+)
+
+// Session key for player using the browser
+const sessionPlayer = "player"
+
+// currentPlayer returns player chosen with apiIdentify, or empty string
+func currentPlayer(session *sessions.Session) string {
+	player, _ := session.Values[sessionPlayer].(string)
+	return player
+}
+
+// apiIdentify remembers which player is using the browser, so pending
+// matches can be shown and confirmed by right person. Player proves it with
+// password admin has set, empty player forgets it.
+func (s *Server) apiIdentify(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.cookies.Get(r, "mysession")
+
+	playerName := r.FormValue("playerName")
+	if playerName == "" {
+		delete(session.Values, sessionPlayer)
+		session.Save(r, w)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	ok, err := s.db.CheckPlayerPassword(r.Context(), playerName, r.FormValue("password"))
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "wrong player or password", http.StatusForbidden)
+		return
+	}
+
+	session.Values[sessionPlayer] = playerName
+
+	session.Save(r, w)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) apiConfirmMatch(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.cookies.Get(r, "mysession")
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	confirm := r.FormValue("answer") == "confirm"
+
+	num, err := s.store(r).ConfirmMatch(r.Context(), id, currentPlayer(session), confirm)
+	if err == database.ErrNotOpponent {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if confirm && num > 0 {
+		s.matchConfirmed(r.Context(), id)
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) apiUpdateGame(w http.ResponseWriter, r *http.Request) {
+	gameName := r.FormValue("gameName")
+	confirm := r.FormValue("confirm") == "confirm"
+
+	_, err := s.store(r).SetGameConfirm(r.Context(), gameName, confirm)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// matchConfirmed tells about match that was pending until now, as if it was
+// just added
+func (s *Server) matchConfirmed(ctx context.Context, id int) {
+	match, err := s.db.GetMatch(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Error("reading confirmed match failed", "match", id, "err", err)
+		return
+	}
+	s.matchChanged(ctx, model.EventMatchCreated, match)
+}
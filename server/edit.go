@@ -0,0 +1,191 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// matchID returns id from path, or from id param of old URLs
+func matchID(r *http.Request) (int, error) {
+	id := r.PathValue("id")
+	if id == "" {
+		id = r.FormValue("id")
+	}
+	return strconv.Atoi(id)
+}
+
+// apiMatch returns match as JSON
+func (s *Server) apiMatch(w http.ResponseWriter, r *http.Request) {
+	id, err := matchID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	match, err := s.db.GetMatch(r.Context(), id)
+	if err == sql.ErrNoRows {
+		s.notFound(w, r)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, match)
+}
+
+func (s *Server) apiEditMatch(w http.ResponseWriter, r *http.Request) {
+	id, err := matchID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	match, err := s.db.GetMatch(r.Context(), id)
+	if err == sql.ErrNoRows {
+		s.notFound(w, r)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	players, err := s.db.GetPlayers(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	games, err := s.db.GetGames(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Match   model.Match
+		Players []model.Player
+		Games   []model.Game
+	}{
+		match,
+		players,
+		games,
+	}
+	s.templates["edit.html"].ExecuteTemplate(w, "base", data)
+}
+
+func (s *Server) apiUpdateMatch(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.cookies.Get(r, "mysession")
+
+	id, err := matchID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	gameName := r.FormValue("gameName")
+	winner := r.FormValue("winner")
+	loser := r.FormValue("loser")
+	comment := r.FormValue("comment")
+
+	if winner == "" || loser == "" {
+		http.Error(w, "winner and loser required", http.StatusBadRequest)
+		return
+	}
+
+	if strings.EqualFold(winner, loser) {
+		http.Error(w, "winner and loser cant be same player", http.StatusBadRequest)
+		return
+	}
+
+	// SQL might cry for empty strings
+	if comment == "" {
+		comment = "EMPTY"
+	}
+
+	match := model.Match{
+		ID:       id,
+		GameName: gameName,
+		Winner:   winner,
+		Loser:    loser,
+		Comment:  comment,
+		IsTie:    r.FormValue("isTie") == "tie",
+	}
+
+	old, err := s.db.GetMatch(r.Context(), id)
+	if err == sql.ErrNoRows {
+		s.notFound(w, r)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	num, err := s.store(r).UpdateMatch(r.Context(), match, s.actor(r))
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if num > 0 {
+		match, err = s.db.GetMatch(r.Context(), id)
+		if err != nil {
+			logError(r, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.matchUpdated(r.Context(), old, match)
+
+		session.AddFlash("Updated game " + strconv.Itoa(id) + ": " + gameName + " | " + winner + " - " + loser)
+		session.Save(r, w)
+	}
+	http.Redirect(w, r, "/matches/"+strconv.Itoa(id)+"/history", http.StatusSeeOther)
+}
+
+func (s *Server) apiMatchHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := matchID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	match, err := s.db.GetMatch(r.Context(), id)
+	if err == sql.ErrNoRows {
+		s.notFound(w, r)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	history, err := s.db.GetMatchHistory(r.Context(), id)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Match   model.Match
+		History []model.MatchChange
+	}{
+		match,
+		history,
+	}
+	s.templates["history.html"].ExecuteTemplate(w, "base", data)
+}
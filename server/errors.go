@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+)
+
+// errorPage renders error with base template
+func (s *Server) errorPage(w http.ResponseWriter, status int, message string) {
+	data := struct {
+		Status  int
+		Title   string
+		Message string
+	}{
+		status,
+		http.StatusText(status),
+		message,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	s.templates["error.html"].ExecuteTemplate(w, "base", data)
+}
+
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	if wantsJSON(r) {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	s.errorPage(w, http.StatusNotFound, "Nothing here.")
+}
+
+func (s *Server) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if wantsJSON(r) {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.errorPage(w, http.StatusMethodNotAllowed, r.Method+" is not allowed here, use "+w.Header().Get("Allow")+".")
+}
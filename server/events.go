@@ -0,0 +1,74 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// event tells subscribers which match was added or deleted, kind is
+// model.EventMatchCreated or model.EventMatchDeleted
+type event struct {
+	Kind  string      `json:"kind"`
+	Match model.Match `json:"match"`
+}
+
+// Events buffered per subscriber, slower ones miss events
+const subscriberBuffer = 16
+
+// bus delivers events to every subscriber. Publish never blocks.
+type bus struct {
+	mu     sync.Mutex
+	subs   map[chan event]bool
+	closed bool
+}
+
+func newBus() *bus {
+	return &bus{subs: make(map[chan event]bool)}
+}
+
+// Subscribe returns channel of events and function to stop subscription.
+// Channel is closed when bus is closed.
+func (b *bus) Subscribe() (<-chan event, func()) {
+	ch := make(chan event, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = true
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.subs[ch] {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends e to subscribers that have room for it
+func (b *bus) Publish(e event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Close ends all subscriptions, so streams end on shutdown
+func (b *bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/tuommii/jumbo/export"
+	"github.com/tuommii/jumbo/model"
+)
+
+// noWriteTimeout lets long download outlive server's write timeout, client
+// going away still cancels request's context
+func noWriteTimeout(w http.ResponseWriter, r *http.Request) {
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil {
+		logError(r, err)
+	}
+}
+
+// exportFormat returns format param, csv by default
+func exportFormat(r *http.Request) string {
+	format := r.FormValue("format")
+	if format == "" {
+		return export.CSV
+	}
+	return format
+}
+
+// apiExportMatches streams matches matching same filter as /api/search
+func (s *Server) apiExportMatches(w http.ResponseWriter, r *http.Request) {
+	format := exportFormat(r)
+	f := filterFromRequest(r)
+
+	mw, err := export.NewMatchWriter(w, format)
+	if err == export.ErrFormat {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	noWriteTimeout(w, r)
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="jumbo-matches.`+format+`"`)
+
+	// Headers are sent with first row, errors can only be logged after it
+	err = s.db.EachMatch(r.Context(), f, mw.Write)
+	if err != nil {
+		logError(r, err)
+		return
+	}
+
+	err = mw.Close()
+	if err != nil {
+		logError(r, err)
+	}
+}
+
+// apiExportStats computes stats from matches while reading them
+func (s *Server) apiExportStats(w http.ResponseWriter, r *http.Request) {
+	format := exportFormat(r)
+	f := filterFromRequest(r)
+
+	if format != export.CSV && format != export.JSON && format != export.NDJSON {
+		http.Error(w, export.ErrFormat.Error(), http.StatusBadRequest)
+		return
+	}
+
+	noWriteTimeout(w, r)
+	players := make(model.StatsMap)
+	err := s.db.EachMatch(r.Context(), f, func(match model.Match) error {
+		players.Init(match.Winner, match.Loser)
+		players.Increase(match.Winner, match.Loser, match.IsTie)
+		return nil
+	})
+	if err != nil {
+		logError(r, err)
+		http.Error(w, "Stats error", http.StatusInternalServerError)
+		return
+	}
+
+	players.CalculateComputed()
+	stats := players.Sorted()
+	sort.Sort(stats)
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="jumbo-stats.`+format+`"`)
+
+	err = export.WriteStats(w, format, stats)
+	if err != nil {
+		logError(r, err)
+	}
+}
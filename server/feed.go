@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/feed"
+	"github.com/tuommii/jumbo/logging"
+	"github.com/tuommii/jumbo/model"
+)
+
+// Matches in feed when limitGames is not given
+const defaultFeedLimit = 50
+
+// apiAtom serves matches matching search filter as Atom
+func (s *Server) apiAtom(w http.ResponseWriter, r *http.Request) {
+	f, err := s.matchFeed(r)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", feed.AtomContentType)
+	err = feed.WriteAtom(w, f, s.baseURL(r)+r.URL.RequestURI())
+	if err != nil {
+		logError(r, err)
+	}
+}
+
+// apiRSS serves matches matching search filter as RSS
+func (s *Server) apiRSS(w http.ResponseWriter, r *http.Request) {
+	f, err := s.matchFeed(r)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", feed.RSSContentType)
+	err = feed.WriteRSS(w, f)
+	if err != nil {
+		logError(r, err)
+	}
+}
+
+func (s *Server) matchFeed(r *http.Request) (feed.Feed, error) {
+	filter := filterFromRequest(r)
+	if filter.LimitGames == 0 {
+		filter.LimitGames = defaultFeedLimit
+	}
+
+	matches, err := s.db.GetMatches(r.Context(), filter)
+	if err != nil {
+		return feed.Feed{}, err
+	}
+
+	base := s.baseURL(r)
+	f := feed.Feed{
+		ID:      "urn:jumbo:matches:" + r.URL.RawQuery,
+		Title:   feedTitle(filter),
+		Link:    base + "/",
+		Updated: time.Now(),
+	}
+
+	for _, m := range matches {
+		added, err := time.Parse(time.RFC3339, m.Added)
+		if err != nil {
+			// One bad row shouldn't break whole feed
+			logging.FromContext(r.Context()).Warn("skipping match with bad time in feed", "match", m.ID, "added", m.Added, "err", err)
+			continue
+		}
+
+		title := m.Winner + " beat " + m.Loser + " at " + m.GameName
+		if m.IsTie {
+			title = m.Winner + " and " + m.Loser + " tied at " + m.GameName
+		}
+		summary := title
+		// Matches without comment have placeholder
+		if m.Comment != "" && m.Comment != "EMPTY" {
+			summary += ": " + m.Comment
+		}
+
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:        "urn:jumbo:match:" + strconv.Itoa(m.ID),
+			Title:     title,
+			Link:      base + "/matches/" + strconv.Itoa(m.ID) + "/history",
+			Summary:   summary,
+			Published: added,
+		})
+	}
+
+	// Matches are latest first
+	if len(f.Entries) > 0 {
+		f.Updated = f.Entries[0].Published
+	}
+	return f, nil
+}
+
+func feedTitle(f model.Filter) string {
+	parts := []string{"Jumbo matches"}
+	if f.GameName != "" {
+		parts = append(parts, f.GameName)
+	}
+	if f.Player1 != "" && f.Player2 != "" {
+		parts = append(parts, f.Player1+" vs "+f.Player2)
+	} else if f.Player1 != "" {
+		parts = append(parts, f.Player1)
+	}
+	return strings.Join(parts, " - ")
+}
+
+// baseURL is BASE_URL, or scheme and host request came to
+func (s *Server) baseURL(r *http.Request) string {
+	if s.publicURL != "" {
+		return s.publicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
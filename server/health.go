@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tuommii/jumbo/database"
+)
+
+const (
+	// Readiness fails when less disk is left for database
+	minDiskFree = 50 << 20
+	// Checks taking longer fail
+	readyTimeout = 5 * time.Second
+)
+
+// check is result of one readiness check
+type check struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Latency float64 `json:"latencyMs"`
+	Detail  string  `json:"detail,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// check statuses
+const (
+	checkOK   = "ok"
+	checkFail = "fail"
+)
+
+// healthz tells process is up, it doesn't touch database so busy database
+// doesn't get server restarted
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, map[string]string{"status": checkOK})
+}
+
+// readyz runs all checks, any failing one makes response 503
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	checks := []check{
+		runCheck(ctx, "database", s.checkDatabase),
+		runCheck(ctx, "schema", s.checkSchema),
+		runCheck(ctx, "templates", s.checkTemplates),
+		runCheck(ctx, "disk", s.checkDisk),
+	}
+
+	status := http.StatusOK
+	overall := checkOK
+	for _, c := range checks {
+		if c.Status != checkOK {
+			status = http.StatusServiceUnavailable
+			overall = checkFail
+		}
+	}
+
+	writeJSON(w, r, status, struct {
+		Status string  `json:"status"`
+		Checks []check `json:"checks"`
+	}{overall, checks})
+}
+
+// runCheck times fn, it returns detail shown with result
+func runCheck(ctx context.Context, name string, fn func(context.Context) (string, error)) check {
+	start := time.Now()
+	detail, err := fn(ctx)
+	c := check{
+		Name:    name,
+		Status:  checkOK,
+		Latency: float64(time.Since(start).Microseconds()) / 1000,
+		Detail:  detail,
+	}
+	if err != nil {
+		c.Status = checkFail
+		c.Error = err.Error()
+	}
+	return c
+}
+
+func (s *Server) checkDatabase(ctx context.Context) (string, error) {
+	return "", s.db.Ping(ctx)
+}
+
+// checkSchema fails if migrations haven't been run, or database was
+// migrated by newer version
+func (s *Server) checkSchema(ctx context.Context) (string, error) {
+	version, err := s.db.Version(ctx)
+	if err != nil {
+		return "", err
+	}
+	detail := fmt.Sprintf("version %d", version)
+	if version != database.SchemaVersion {
+		return detail, fmt.Errorf("schema version %d, want %d", version, database.SchemaVersion)
+	}
+	return detail, nil
+}
+
+func (s *Server) checkTemplates(ctx context.Context) (string, error) {
+	if len(s.templates) == 0 {
+		return "", errors.New("no templates parsed")
+	}
+	for name, t := range s.templates {
+		if t == nil || t.Lookup("base") == nil {
+			return "", fmt.Errorf("template %s not parsed", name)
+		}
+	}
+	return fmt.Sprintf("%d templates", len(s.templates)), nil
+}
+
+func (s *Server) checkDisk(ctx context.Context) (string, error) {
+	free, err := s.db.DiskFree(ctx)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return "not supported", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	detail := fmt.Sprintf("%d MB free", free>>20)
+	if free < minDiskFree {
+		return detail, fmt.Errorf("less than %d MB free", minDiskFree>>20)
+	}
+	return detail, nil
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/importer"
+	"github.com/tuommii/jumbo/model"
+)
+
+// Biggest accepted upload
+const maxImportSize = 10 << 20
+
+// importPage is import form or dry run report
+type importPage struct {
+	// Where report posts back to
+	Action        string
+	Format        string
+	Report        *importer.Report
+	Data          string
+	CreateMissing bool
+	Committed     bool
+	Imported      int64
+	// PGN only
+	GameName string
+	Games    []model.Game
+	Aliases  []model.Alias
+}
+
+// adminImport shows dry run report of uploaded CSV. Report page posts same
+// CSV back with commit set, which saves it.
+func (s *Server) adminImport(w http.ResponseWriter, r *http.Request) {
+	page := importPage{Action: "/admin/import", Format: "CSV"}
+
+	if r.Method != "POST" {
+		s.templates["import.html"].ExecuteTemplate(w, "base", page)
+		return
+	}
+
+	data, err := importData(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := importer.ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.importRows(w, r, page, data, rows)
+}
+
+// importRows checks rows and saves them if commit is set
+func (s *Server) importRows(w http.ResponseWriter, r *http.Request, page importPage, data []byte, rows []importer.Row) {
+	createMissing := r.FormValue("createMissing") == "create"
+
+	rep, err := s.checkImport(r.Context(), rows, createMissing)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var imported int64
+	committed := r.FormValue("commit") == "commit"
+
+	if committed {
+		if !rep.CanImport() {
+			http.Error(w, "fix errors before importing", http.StatusBadRequest)
+			return
+		}
+
+		imported, err = s.store(r).ImportMatches(r.Context(), rep.Import())
+		if errors.Is(err, database.ErrInTrash) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			logError(r, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	page.Report = rep
+	page.Data = string(data)
+	page.CreateMissing = createMissing
+	page.Committed = committed
+	page.Imported = imported
+	s.templates["import.html"].ExecuteTemplate(w, "base", page)
+}
+
+// importData returns uploaded file, or data posted back from report
+func importData(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	err := r.ParseMultipartForm(maxImportSize)
+	if err != nil && err != http.ErrNotMultipart {
+		return nil, err
+	}
+
+	file, _, err := r.FormFile("file")
+	if err == nil {
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+
+	return []byte(strings.TrimSpace(r.FormValue("data"))), nil
+}
+
+func (s *Server) checkImport(ctx context.Context, rows []importer.Row, createMissing bool) (*importer.Report, error) {
+	players, err := s.db.GetPlayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	games, err := s.db.GetGames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed, err := s.db.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.db.GetMatches(ctx, model.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	return importer.Check(rows, players, games, trashed, existing, createMissing), nil
+}
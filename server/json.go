@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// wantsJSON tells if client asked JSON instead of redirect to page
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		logError(r, err)
+	}
+}
+
+// apiPlayers lists players as JSON ordered by name
+func (s *Server) apiPlayers(w http.ResponseWriter, r *http.Request) {
+	players, err := s.db.GetPlayers(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	writeJSON(w, r, http.StatusOK, players)
+}
+
+// apiGames lists games as JSON ordered by name
+func (s *Server) apiGames(w http.ResponseWriter, r *http.Request) {
+	games, err := s.db.GetGames(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sort.Slice(games, func(i, j int) bool { return games[i].Name < games[j].Name })
+	writeJSON(w, r, http.StatusOK, games)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+func (s *Server) apiLadder(w http.ResponseWriter, r *http.Request) {
+	gameName := r.FormValue("gameName")
+
+	ladder, err := s.db.GetLadder(r.Context(), gameName)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	challenges, err := s.db.GetOpenChallenges(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	open := make([]model.Challenge, 0)
+	for _, c := range challenges {
+		if c.GameName == gameName {
+			open = append(open, c)
+		}
+	}
+
+	data := struct {
+		GameName   string
+		Ladder     []model.Rung
+		Challenges []model.Challenge
+	}{
+		gameName,
+		ladder,
+		open,
+	}
+	s.templates["ladder.html"].ExecuteTemplate(w, "base", data)
+}
+
+func (s *Server) apiCreateChallenge(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.cookies.Get(r, "mysession")
+
+	gameName := r.FormValue("gameName")
+	challenger := r.FormValue("challenger")
+	opponent := r.FormValue("opponent")
+
+	if challenger == "" || opponent == "" {
+		http.Error(w, "challenger and opponent required", http.StatusBadRequest)
+		return
+	}
+
+	if strings.EqualFold(challenger, opponent) {
+		http.Error(w, "challenger and opponent cant be same player", http.StatusBadRequest)
+		return
+	}
+
+	c := model.Challenge{
+		GameName:   gameName,
+		Challenger: challenger,
+		Opponent:   opponent,
+	}
+
+	_, err := s.store(r).CreateChallenge(r.Context(), c)
+	if err == model.ErrChallengeRange || err == model.ErrChallengeOpen || err == model.ErrChallengePlayer {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	session.AddFlash("New challenge: " + gameName + " | " + challenger + " - " + opponent)
+	session.Save(r, w)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// apiAnswerChallenge answers challenge as identified player
+func (s *Server) apiAnswerChallenge(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.cookies.Get(r, "mysession")
+
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accept := r.FormValue("answer") == "accept"
+
+	_, err = s.store(r).AnswerChallenge(r.Context(), id, currentPlayer(session), accept)
+	if err == model.ErrNotChallenged {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err == model.ErrNoChallenge {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// Swiss rounds when not given
+const defaultSwissRounds = 5
+
+func (s *Server) apiLeague(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	gameName := r.FormValue("gameName")
+	format := r.FormValue("format")
+
+	rounds, err := strconv.Atoi(r.FormValue("rounds"))
+	if err != nil || rounds <= 0 {
+		rounds = defaultSwissRounds
+	}
+
+	ld, err := strconv.Atoi(r.FormValue("limitDays"))
+	if err != nil {
+		ld = 0
+	}
+
+	all, err := s.db.GetPlayers(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Only players that exist, in order they are listed
+	selected := make(map[string]bool)
+	for _, name := range r.Form["players"] {
+		selected[name] = true
+	}
+	players := make([]model.Player, 0)
+	for _, p := range all {
+		if selected[p.Name] {
+			players = append(players, p)
+		}
+	}
+
+	if len(players) < 2 {
+		http.Error(w, "at least two players required", http.StatusBadRequest)
+		return
+	}
+	if len(players) > model.MaxLeaguePlayers {
+		http.Error(w, "at most "+strconv.Itoa(model.MaxLeaguePlayers)+" players", http.StatusBadRequest)
+		return
+	}
+
+	matches, err := s.db.GetMatches(r.Context(), model.Filter{GameName: gameName, LimitDays: ld})
+	if err != nil {
+		logError(r, err)
+		http.Error(w, "Search error", http.StatusInternalServerError)
+		return
+	}
+
+	var fixtures []model.Fixture
+
+	switch format {
+	case model.SwissFormat:
+		fixtures = model.Swiss(players, gameName, matches, rounds)
+	case model.DoubleRoundRobinFormat:
+		fixtures = model.RoundRobin(players, gameName, true)
+		model.MarkPlayed(fixtures, matches)
+	default:
+		format = model.RoundRobinFormat
+		fixtures = model.RoundRobin(players, gameName, false)
+		model.MarkPlayed(fixtures, matches)
+	}
+
+	data := struct {
+		GameName  string
+		Format    string
+		Standings model.Standings
+		Rounds    [][]model.Fixture
+	}{
+		gameName,
+		format,
+		model.LeagueStandings(players, fixtures, matches),
+		model.GroupRounds(fixtures),
+	}
+	s.templates["league.html"].ExecuteTemplate(w, "base", data)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+const (
+	// Comment sent this often keeps proxies from closing idle stream
+	liveHeartbeat = 30 * time.Second
+	// Matches sent with standings
+	liveMatches = 10
+)
+
+// standings is what TV page shows
+type standings struct {
+	GameName string            `json:"gameName"`
+	Stats    model.SortedStats `json:"stats"`
+	Matches  []model.Match     `json:"matches"`
+}
+
+func (s *Server) standings(r *http.Request, gameName string) (standings, error) {
+	matches, err := s.db.GetMatches(r.Context(), model.Filter{GameName: gameName})
+	if err != nil {
+		return standings{}, err
+	}
+
+	stats, err := model.StatsFromMatches(matches)
+	if err != nil {
+		return standings{}, err
+	}
+	sort.Sort(stats)
+
+	if len(matches) > liveMatches {
+		matches = matches[:liveMatches]
+	}
+	return standings{gameName, stats, matches}, nil
+}
+
+// apiTV shows standings of game full screen, page updates itself from /live
+func (s *Server) apiTV(w http.ResponseWriter, r *http.Request) {
+	data, err := s.standings(r, r.FormValue("gameName"))
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.templates["live.html"].ExecuteTemplate(w, "base", data)
+}
+
+// apiLive streams Server-Sent Events for game: standings when connected
+// and after every change, and match when one is added or deleted
+func (s *Server) apiLive(w http.ResponseWriter, r *http.Request) {
+	gameName := r.FormValue("gameName")
+	rc := http.NewResponseController(w)
+
+	// Stream lives until client leaves
+	noWriteTimeout(w, r)
+
+	events, unsubscribe := s.events.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	err := s.sendStandings(w, r, gameName)
+	if err != nil {
+		logError(r, err)
+		return
+	}
+	rc.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if gameName != "" && !strings.EqualFold(e.Match.GameName, gameName) {
+				continue
+			}
+			err = writeEvent(w, "match", e)
+			if err == nil {
+				err = s.sendStandings(w, r, gameName)
+			}
+		}
+		if err != nil {
+			logError(r, err)
+			return
+		}
+		rc.Flush()
+	}
+}
+
+func (s *Server) sendStandings(w http.ResponseWriter, r *http.Request, gameName string) error {
+	data, err := s.standings(r, gameName)
+	if err != nil {
+		return err
+	}
+	return writeEvent(w, "standings", data)
+}
+
+// writeEvent writes v as JSON in event named name
+func writeEvent(w http.ResponseWriter, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tuommii/jumbo/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec(
+		"jumbo_http_requests_total",
+		"HTTP requests by route, method and status.",
+		"route", "method", "status",
+	)
+	httpDuration = metrics.NewHistogramVec(
+		"jumbo_http_request_duration_seconds",
+		"HTTP request latencies by route and method.",
+		metrics.DefaultBuckets,
+		"route", "method",
+	)
+)
+
+func init() {
+	metrics.Default.Register(httpRequests, httpDuration)
+}
+
+// withMetrics counts requests by route pattern, so path parameters don't
+// make new series. Must be last middleware to see pattern router sets.
+func withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(sw, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.Inc(route, r.Method, strconv.Itoa(sw.Status()))
+		httpDuration.Observe(time.Since(start).Seconds(), route, r.Method)
+	})
+}
+
+// apiMetrics writes registered metrics and gauges read from database now
+func (s *Server) apiMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	total, err := s.db.CountMatches(ctx, 0)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	recent, err := s.db.CountMatches(ctx, 1)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ratings, err := s.db.GetRatings(ctx)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	matchesTotal := metrics.NewGaugeVec("jumbo_matches", "Confirmed matches by game.", "game")
+	for game, count := range total {
+		matchesTotal.Set(float64(count), game)
+	}
+
+	matchesRecent := metrics.NewGaugeVec("jumbo_matches_last_24h", "Confirmed matches by game in last 24 hours.", "game")
+	for game := range total {
+		matchesRecent.Set(float64(recent[game]), game)
+	}
+
+	rating := metrics.NewGaugeVec("jumbo_player_rating", "Player's current Elo rating by game.", "game", "player")
+	for _, rt := range ratings {
+		rating.Set(rt.Rating, rt.GameName, rt.Player)
+	}
+
+	stats := s.db.PoolStats(ctx)
+	pool := metrics.NewGaugeVec("jumbo_db_connections", "Database connections by state.", "state")
+	pool.Set(float64(stats.InUse), "in_use")
+	pool.Set(float64(stats.Idle), "idle")
+	pool.Set(float64(stats.MaxOpenConnections), "max_open")
+
+	poolWaits := metrics.NewGaugeVec("jumbo_db_wait_count", "Total connections waited for.")
+	poolWaits.Set(float64(stats.WaitCount))
+	poolWaitTime := metrics.NewGaugeVec("jumbo_db_wait_duration_seconds", "Total time waited for connections.")
+	poolWaitTime.Set(stats.WaitDuration.Seconds())
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+
+	reg := &metrics.Registry{}
+	reg.Register(metrics.Default, matchesTotal, matchesRecent, rating, pool, poolWaits, poolWaitTime)
+	err = reg.Write(w)
+	if err != nil {
+		logError(r, err)
+	}
+}
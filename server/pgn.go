@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/tuommii/jumbo/importer"
+	"github.com/tuommii/jumbo/model"
+)
+
+// adminPGN imports PGN games as matches of chosen game, same dry run and
+// commit steps as CSV import
+func (s *Server) adminPGN(w http.ResponseWriter, r *http.Request) {
+	games, err := s.db.GetGames(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	aliases, err := s.db.GetAliases(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	page := importPage{Action: "/admin/pgn", Format: "PGN", Games: games, Aliases: aliases}
+
+	if r.Method != "POST" {
+		s.templates["import.html"].ExecuteTemplate(w, "base", page)
+		return
+	}
+
+	data, err := importData(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page.GameName = strings.TrimSpace(r.FormValue("gameName"))
+	if page.GameName == "" {
+		http.Error(w, "game required", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := importer.ReadPGN(bytes.NewReader(data), page.GameName, aliases)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.importRows(w, r, page, data, rows)
+}
+
+func (s *Server) adminSetAlias(w http.ResponseWriter, r *http.Request) {
+	alias := model.Alias{
+		Alias:      strings.TrimSpace(r.FormValue("alias")),
+		PlayerName: r.FormValue("playerName"),
+	}
+	if alias.Alias == "" || alias.PlayerName == "" {
+		http.Error(w, "alias and player required", http.StatusBadRequest)
+		return
+	}
+
+	_, err := s.store(r).SetAlias(r.Context(), alias)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/pgn", http.StatusSeeOther)
+}
+
+func (s *Server) adminDeleteAlias(w http.ResponseWriter, r *http.Request) {
+	_, err := s.store(r).DeleteAlias(r.Context(), r.FormValue("alias"))
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/pgn", http.StatusSeeOther)
+}
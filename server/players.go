@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/tuommii/jumbo/model"
+)
+
+// adminPlayers has form for setting passwords players identify with
+func (s *Server) adminPlayers(w http.ResponseWriter, r *http.Request) {
+	players, err := s.db.GetPlayers(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Players []model.Player
+	}{
+		players,
+	}
+	s.templates["players.html"].ExecuteTemplate(w, "base", data)
+}
+
+func (s *Server) adminSetPassword(w http.ResponseWriter, r *http.Request) {
+	num, err := s.store(r).SetPlayerPassword(r.Context(), r.FormValue("playerName"), r.FormValue("password"))
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if num == 0 {
+		http.Error(w, "no such player", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/players", http.StatusSeeOther)
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Middleware wraps handler, first added runs first
+type Middleware func(http.Handler) http.Handler
+
+// router matches method and path. Patterns are split like paths,
+// {name} segment matches any segment and is available with r.PathValue,
+// pattern ending with /* matches everything below it.
+type router struct {
+	routes     []route
+	middleware []Middleware
+	// Called when nothing matches, or only path matches
+	notFound         http.HandlerFunc
+	methodNotAllowed http.HandlerFunc
+}
+
+type route struct {
+	method   string
+	pattern  string
+	segments []string
+	prefix   bool
+	handler  http.Handler
+}
+
+func newRouter() *router {
+	return &router{
+		notFound: http.NotFound,
+		methodNotAllowed: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		},
+	}
+}
+
+// Use adds middleware run for every request, also unmatched ones
+func (rt *router) Use(mw ...Middleware) {
+	rt.middleware = append(rt.middleware, mw...)
+}
+
+// Handle adds route, GET routes also answer HEAD
+func (rt *router) Handle(method string, pattern string, h http.Handler) {
+	segments := getParams(pattern)
+	prefix := len(segments) > 0 && segments[len(segments)-1] == "*"
+	if prefix {
+		segments = segments[:len(segments)-1]
+	}
+
+	rt.routes = append(rt.routes, route{method, pattern, segments, prefix, h})
+}
+
+// HandleFunc adds route for handler function
+func (rt *router) HandleFunc(method string, pattern string, h http.HandlerFunc) {
+	rt.Handle(method, pattern, h)
+}
+
+// Get and Post are shorthands for HandleFunc
+func (rt *router) Get(pattern string, h http.HandlerFunc) {
+	rt.HandleFunc("GET", pattern, h)
+}
+
+func (rt *router) Post(pattern string, h http.HandlerFunc) {
+	rt.HandleFunc("POST", pattern, h)
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var h http.Handler = http.HandlerFunc(rt.dispatch)
+	for i := len(rt.middleware) - 1; i >= 0; i-- {
+		h = rt.middleware[i](h)
+	}
+	h.ServeHTTP(w, r)
+}
+
+func (rt *router) dispatch(w http.ResponseWriter, r *http.Request) {
+	path := getParams(r.URL.Path)
+	allowed := make(map[string]bool)
+
+	for _, route := range rt.routes {
+		values, ok := route.match(path)
+		if !ok {
+			continue
+		}
+
+		if route.method != r.Method && !(route.method == "GET" && r.Method == "HEAD") {
+			allowed[route.method] = true
+			continue
+		}
+
+		// Middleware sees pattern too, it has same request
+		r.Pattern = route.pattern
+		for name, value := range values {
+			r.SetPathValue(name, value)
+		}
+		route.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if len(allowed) > 0 {
+		methods := make([]string, 0, len(allowed))
+		for method := range allowed {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		rt.methodNotAllowed(w, r)
+		return
+	}
+
+	rt.notFound(w, r)
+}
+
+// match returns path values if path matches route
+func (rt route) match(path []string) (map[string]string, bool) {
+	if len(path) < len(rt.segments) || (!rt.prefix && len(path) != len(rt.segments)) {
+		return nil, false
+	}
+
+	values := make(map[string]string)
+	for i, segment := range rt.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			values[segment[1:len(segment)-1]] = path[i]
+			continue
+		}
+		if segment != path[i] {
+			return nil, false
+		}
+	}
+	return values, true
+}
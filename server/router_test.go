@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testRouter answers with name of matched route and its path values
+func testRouter() *router {
+	rt := newRouter()
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s id=%s name=%s", name, r.PathValue("id"), r.PathValue("name"))
+		}
+	}
+
+	rt.Get("/", handler("home"))
+	rt.Get("/matches/{id}", handler("match"))
+	rt.Post("/matches/{id}", handler("update"))
+	rt.Get("/matches/{id}/edit", handler("edit"))
+	rt.Get("/players/{name}/matches/{id}", handler("player match"))
+	rt.Post("/api/create/player", handler("create"))
+	rt.Get("/static/*", handler("static"))
+	rt.HandleFunc("DELETE", "/matches/{id}", handler("delete"))
+	return rt
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+	}{
+		{"GET", "/", 200, "home id= name=", ""},
+		{"GET", "/matches/12", 200, "match id=12 name=", ""},
+		{"GET", "/matches/12/", 200, "match id=12 name=", ""},
+		{"GET", "//matches//12", 200, "match id=12 name=", ""},
+		{"POST", "/matches/12", 200, "update id=12 name=", ""},
+		{"DELETE", "/matches/12", 200, "delete id=12 name=", ""},
+		{"GET", "/matches/12/edit", 200, "edit id=12 name=", ""},
+		{"GET", "/players/alice/matches/3", 200, "player match id=3 name=alice", ""},
+		{"GET", "/static/js/live.js", 200, "static id= name=", ""},
+		{"GET", "/static", 200, "static id= name=", ""},
+		{"HEAD", "/matches/12", 200, "", ""},
+		{"GET", "/matches", 404, "404 page not found\n", ""},
+		{"GET", "/matches/12/history", 404, "404 page not found\n", ""},
+		{"GET", "/nothing", 404, "404 page not found\n", ""},
+		{"PUT", "/matches/12", 405, "Method not allowed\n", "DELETE, GET, POST"},
+		{"GET", "/api/create/player", 405, "Method not allowed\n", "POST"},
+		{"POST", "/static/style.css", 405, "Method not allowed\n", "GET"},
+		{"HEAD", "/api/create/player", 405, "", "POST"},
+	}
+
+	rt := testRouter()
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.status {
+				t.Errorf("got status %d, want %d", w.Code, tt.status)
+			}
+			if tt.method != "HEAD" && w.Body.String() != tt.body {
+				t.Errorf("got body %q, want %q", w.Body.String(), tt.body)
+			}
+			if got := w.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("got Allow %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	rt := testRouter()
+
+	var order []string
+	var pattern string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+				pattern = r.Pattern
+			})
+		}
+	}
+	rt.Use(mw("first"), mw("second"))
+
+	tests := []struct {
+		path    string
+		pattern string
+	}{
+		{"/matches/1/edit", "/matches/{id}/edit"},
+		{"/static/a/b", "/static/*"},
+		{"/nothing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			order = nil
+			pattern = ""
+			rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+
+			if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+				t.Errorf("middleware ran in order %v, want [first second]", order)
+			}
+			if pattern != tt.pattern {
+				t.Errorf("middleware saw pattern %q, want %q", pattern, tt.pattern)
+			}
+		})
+	}
+}
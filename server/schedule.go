@@ -0,0 +1,189 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/ical"
+	"github.com/tuommii/jumbo/model"
+)
+
+const (
+	// Events without duration last this long
+	defaultEventDuration = time.Hour
+	// Calendar feeds keep ended events this many days
+	calendarPastDays = 90
+	// Format of datetime-local input
+	datetimeLocal = "2006-01-02T15:04"
+)
+
+// apiEvents lists upcoming events and has form for new ones
+func (s *Server) apiEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := s.db.GetScheduledEvents(r.Context(), model.EventFilter{})
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	players, err := s.db.GetPlayers(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	games, err := s.db.GetGames(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Events  []model.ScheduledEvent
+		Players []model.Player
+		Games   []model.Game
+	}{
+		events,
+		players,
+		games,
+	}
+	s.templates["events.html"].ExecuteTemplate(w, "base", data)
+}
+
+// apiCreateEvent schedules event. Start is local time of server.
+func (s *Server) apiCreateEvent(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	starts, err := time.ParseInLocation(datetimeLocal, r.FormValue("starts"), time.Local)
+	if err != nil {
+		http.Error(w, "start time required", http.StatusBadRequest)
+		return
+	}
+
+	duration := defaultEventDuration
+	if minutes, err := strconv.Atoi(r.FormValue("minutes")); err == nil && minutes > 0 {
+		duration = time.Duration(minutes) * time.Minute
+	}
+
+	e := model.ScheduledEvent{
+		GameName: r.FormValue("gameName"),
+		Title:    strings.TrimSpace(r.FormValue("title")),
+		Players:  r.Form["players"],
+		Starts:   starts.Format(time.RFC3339),
+		Ends:     starts.Add(duration).Format(time.RFC3339),
+		Location: strings.TrimSpace(r.FormValue("location")),
+	}
+	if e.GameName == "" {
+		http.Error(w, "game required", http.StatusBadRequest)
+		return
+	}
+	if e.Title == "" {
+		e.Title = eventTitle(e)
+	}
+
+	_, err = s.store(r).CreateScheduledEvent(r.Context(), e)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/events", http.StatusSeeOther)
+}
+
+func (s *Server) apiDeleteEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.store(r).DeleteScheduledEvent(r.Context(), id)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/events", http.StatusSeeOther)
+}
+
+// apiCalendar serves events of player or game as iCalendar
+func (s *Server) apiCalendar(w http.ResponseWriter, r *http.Request) {
+	f := model.EventFilter{
+		GameName: r.FormValue("gameName"),
+		Player:   r.FormValue("player"),
+		PastDays: calendarPastDays,
+	}
+
+	events, err := s.db.GetScheduledEvents(r.Context(), f)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := "Jumbo"
+	if f.Player != "" {
+		name += " - " + f.Player
+	}
+	if f.GameName != "" {
+		name += " - " + f.GameName
+	}
+
+	base := s.baseURL(r)
+	cal := ical.Calendar{Name: name}
+	for _, e := range events {
+		starts, _ := time.Parse(time.RFC3339, e.Starts)
+		ends, _ := time.Parse(time.RFC3339, e.Ends)
+		created, _ := time.Parse(time.RFC3339, e.Created)
+		record := base + recordURL(e)
+
+		description := e.GameName
+		if len(e.Players) > 0 {
+			description += ": " + strings.Join(e.Players, ", ")
+		}
+		description += "\nRecord result: " + record
+
+		cal.Events = append(cal.Events, ical.Event{
+			UID:         "event-" + strconv.Itoa(e.ID) + "@jumbo",
+			Start:       starts,
+			End:         ends,
+			Created:     created,
+			Summary:     e.Title,
+			Location:    e.Location,
+			Description: description,
+			URL:         record,
+		})
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", `inline; filename="jumbo.ics"`)
+	err = ical.Write(w, cal)
+	if err != nil {
+		logError(r, err)
+	}
+}
+
+// recordURL is match form with event's game, and players if there are two
+func recordURL(e model.ScheduledEvent) string {
+	v := url.Values{}
+	v.Set("gameName", e.GameName)
+	if len(e.Players) == 2 {
+		v.Set("winner", e.Players[0])
+		v.Set("loser", e.Players[1])
+	}
+	return "/?" + v.Encode() + "#createMatchSection"
+}
+
+func eventTitle(e model.ScheduledEvent) string {
+	if len(e.Players) == 2 {
+		return e.Players[0] + " vs " + e.Players[1] + " (" + e.GameName + ")"
+	}
+	return e.GameName + " night"
+}
@@ -1,25 +1,44 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
 
-	"github.com/gorilla/context"
+	gcontext "github.com/gorilla/context"
 	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/logging"
 )
 
 const (
 	tmplDir  = "templates/"
 	baseTmpl = "base.html"
-	username = "lol"
-	password = "lol"
+	// How often expired challenges and pending matches are handled
+	expireInterval = time.Minute
+	// Pending matches are handled after this if CONFIRM_TIMEOUT is not set
+	defaultConfirmTimeout = 72 * time.Hour
+	// Scheduled backups when BACKUP_DIR is set
+	defaultBackupInterval = 24 * time.Hour
+	defaultBackupKeep     = 7
+	// http.Server timeouts, exports and backup download clear write timeout
+	readTimeout  = 30 * time.Second
+	writeTimeout = 60 * time.Second
+	idleTimeout  = 120 * time.Second
+	// Heroku kills process 30 seconds after SIGTERM
+	shutdownTimeout = 25 * time.Second
+	username        = "lol"
+	password        = "lol"
 )
 
 // Server ...
@@ -28,46 +47,227 @@ type Server struct {
 	// Hold's all templates, filename is key
 	templates map[string]*template.Template
 	cookies   *sessions.CookieStore
+	// Pending matches older than this are confirmed or rejected
+	confirmTimeout   time.Duration
+	confirmOnTimeout bool
+	adminUsername    string
+	adminPassword    string
+	// Bearer tokens accepted by API besides basic auth
+	apiTokens []string
+	// Absolute links use this instead of request's host, if set
+	publicURL string
+	// Slash commands are verified with Slack's signing secret or
+	// Mattermost's command tokens
+	slackSecret      string
+	mattermostTokens []string
+	// Empty disables scheduled backups
+	backupDir      string
+	backupInterval time.Duration
+	backupKeep     int
+	// Match changes for live streams
+	events *bus
+	// Wakes webhook sender when deliveries are queued
+	webhookWake chan struct{}
+	// Signs share link tokens
+	shareCodec *securecookie.SecureCookie
 }
 
 // Create new server instance
-func Create(db database.Database) *Server {
-	return &Server{db: db, cookies: sessions.NewCookieStore([]byte("helloworld"))}
+func Create(db database.Database) (*Server, error) {
+	timeout, err := time.ParseDuration(os.Getenv("CONFIRM_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = defaultConfirmTimeout
+	}
+
+	backupInterval, err := time.ParseDuration(os.Getenv("BACKUP_INTERVAL"))
+	if err != nil || backupInterval <= 0 {
+		backupInterval = defaultBackupInterval
+	}
+
+	backupKeep, err := strconv.Atoi(os.Getenv("BACKUP_KEEP"))
+	if err != nil {
+		backupKeep = defaultBackupKeep
+	}
+
+	// Session tells which player is using the browser, it must not be
+	// forgeable
+	sessionKey, err := secret(db, "SESSION_KEY", "session")
+	if err != nil {
+		return nil, err
+	}
+
+	// Share links must keep working over restarts
+	shareKey, err := secret(db, "SHARE_KEY", "share")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		db:               db,
+		cookies:          sessions.NewCookieStore(sessionKey),
+		confirmTimeout:   timeout,
+		confirmOnTimeout: os.Getenv("CONFIRM_ON_TIMEOUT") != "reject",
+		adminUsername:    getenv("ADMIN_USERNAME", username),
+		adminPassword:    getenv("ADMIN_PASSWORD", password),
+		apiTokens:        splitList(os.Getenv("API_TOKENS")),
+		publicURL:        strings.TrimSuffix(os.Getenv("BASE_URL"), "/"),
+		slackSecret:      os.Getenv("SLACK_SIGNING_SECRET"),
+		mattermostTokens: splitList(os.Getenv("MATTERMOST_TOKENS")),
+		backupDir:        os.Getenv("BACKUP_DIR"),
+		backupInterval:   backupInterval,
+		backupKeep:       backupKeep,
+		events:           newBus(),
+		webhookWake:      make(chan struct{}, 1),
+		shareCodec:       newShareCodec(shareKey),
+	}, nil
+}
+
+// secret returns key from environment variable, or key stored in database
+func secret(db database.Database, env string, name string) ([]byte, error) {
+	if key := os.Getenv(env); key != "" {
+		return []byte(key), nil
+	}
+	return db.GetSecret(context.Background(), name)
 }
 
-// Start server
-func (s *Server) Start() error {
+// Start server, returns after ctx is cancelled and in-flight requests are
+// done
+func (s *Server) Start(ctx context.Context) error {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	go s.expire(ctx, expireInterval)
+	go s.deliverWebhooks(ctx, webhookInterval)
+	if s.backupDir != "" {
+		go s.backup(ctx, s.backupInterval)
+	}
+
+	srv := &http.Server{
+		Addr:         "0.0.0.0:" + port,
+		Handler:      s.Handler(),
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+	// Shutdown waits for streams, ending them lets it finish
+	srv.RegisterOnShutdown(s.events.Close)
+
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", srv.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// Handler returns all routes with middleware. Templates are loaded from
+// templates directory.
+func (s *Server) Handler() http.Handler {
 	fmap := template.FuncMap{
 		"FormatPercentage": FormatPercentage,
 		"FormatDate":       FormatDate,
+		"FormatTime":       FormatTime,
+		"FormatPoints":     FormatPoints,
+		"inc":              inc,
+		"list":             list,
+		"recordURL":        recordURL,
 	}
 
 	// Cache templates
 	s.initTemplates(baseTmpl, fmap)
 
+	rt := newRouter()
+	rt.notFound = s.notFound
+	rt.methodNotAllowed = s.methodNotAllowed
+	rt.Use(gcontext.ClearHandler, s.withLogging, withMetrics)
+
 	fs := http.FileServer(http.Dir("static"))
-	http.Handle("/static/", http.StripPrefix("/static/", fs))
+	rt.Handle("GET", "/static/*", http.StripPrefix("/static/", fs))
+
+	rt.Post("/api/create/match", s.auth(s.apiCreateMatch))
+	rt.Post("/api/create/game", s.auth(s.apiCreateGame))
+	rt.Post("/api/create/player", s.auth(s.apiCreatePlayer))
+
+	rt.Post("/api/delete/match", s.auth(s.apiDeleteMatch))
+	rt.Post("/api/delete/game", s.auth(s.apiDeleteGame))
+	rt.Post("/api/delete/player", s.auth(s.apiDeletePlayer))
+
+	rt.Post("/api/update/game", s.auth(s.apiUpdateGame))
+	rt.Post("/api/update/match", s.auth(s.apiUpdateMatch))
+	rt.Get("/api/edit/match", s.auth(s.apiEditMatch))
+	rt.Get("/api/history/match", s.apiMatchHistory)
+	rt.Post("/api/confirm/match", s.auth(s.apiConfirmMatch))
+	rt.Post("/api/identify", s.auth(s.apiIdentify))
+
+	rt.Get("/matches/{id}", s.apiMatch)
+	rt.Get("/matches/{id}/edit", s.auth(s.apiEditMatch))
+	rt.Post("/matches/{id}/edit", s.auth(s.apiUpdateMatch))
+	rt.Get("/matches/{id}/history", s.apiMatchHistory)
+
+	rt.Post("/api/restore", s.auth(s.apiRestore))
 
-	http.HandleFunc("/api/create/match", auth(s.apiCreateMatch))
-	http.HandleFunc("/api/create/game", auth(s.apiCreateGame))
-	http.HandleFunc("/api/create/player", auth(s.apiCreatePlayer))
+	rt.Get("/admin/trash", s.adminAuth(s.adminTrash))
+	rt.Post("/admin/restore", s.adminAuth(s.adminRestore))
+	rt.Post("/admin/purge", s.adminAuth(s.adminPurge))
+	rt.Get("/admin/audit", s.adminAuth(s.adminAudit))
+	rt.Get("/admin/import", s.adminAuth(s.adminImport))
+	rt.Post("/admin/import", s.adminAuth(s.adminImport))
+	rt.Get("/admin/backup", s.adminAuth(s.adminBackup))
+	rt.Get("/admin/pgn", s.adminAuth(s.adminPGN))
+	rt.Post("/admin/pgn", s.adminAuth(s.adminPGN))
+	rt.Post("/admin/alias/set", s.adminAuth(s.adminSetAlias))
+	rt.Post("/admin/alias/delete", s.adminAuth(s.adminDeleteAlias))
+	rt.Get("/admin/webhooks", s.adminAuth(s.adminWebhooks))
+	rt.Post("/admin/webhooks/create", s.adminAuth(s.adminCreateWebhook))
+	rt.Post("/admin/webhooks/delete", s.adminAuth(s.adminDeleteWebhook))
+	rt.Post("/admin/webhooks/ping", s.adminAuth(s.adminPingWebhook))
+	rt.Post("/admin/webhooks/retry", s.adminAuth(s.adminRetryDelivery))
+	rt.Get("/admin/players", s.adminAuth(s.adminPlayers))
+	rt.Post("/admin/players/password", s.adminAuth(s.adminSetPassword))
+	rt.Get("/admin/shares", s.adminAuth(s.adminShares))
+	rt.Post("/admin/shares/create", s.adminAuth(s.adminCreateShare))
+	rt.Post("/admin/shares/revoke", s.adminAuth(s.adminRevokeShare))
 
-	http.HandleFunc("/api/delete/match", auth(s.apiDeleteMatch))
-	http.HandleFunc("/api/delete/game", auth(s.apiDeleteGame))
-	http.HandleFunc("/api/delete/player", auth(s.apiDeletePlayer))
+	rt.Post("/api/create/challenge", s.auth(s.apiCreateChallenge))
+	rt.Post("/api/answer/challenge", s.auth(s.apiAnswerChallenge))
 
-	http.HandleFunc("/api/search", s.apiSearch)
-	http.HandleFunc("/favicon.png", s.favicon)
-	http.HandleFunc("/", s.apiHome)
+	rt.Get("/api/players", s.apiPlayers)
+	rt.Get("/api/games", s.apiGames)
+	rt.Post("/api/search", s.apiSearch)
+	rt.Post("/api/league", s.apiLeague)
+	rt.Get("/api/export/matches", s.apiExportMatches)
+	rt.Get("/api/export/stats", s.apiExportStats)
+	rt.Post("/api/ladder", s.apiLadder)
+	rt.Get("/events", s.apiEvents)
+	rt.Post("/api/create/event", s.auth(s.apiCreateEvent))
+	rt.Post("/api/delete/event", s.auth(s.apiDeleteEvent))
+	rt.Get("/calendar.ics", s.apiCalendar)
+	rt.Get("/feed.atom", s.apiAtom)
+	rt.Get("/feed.rss", s.apiRSS)
+	rt.Get("/badge.svg", s.apiBadge)
+	rt.Get("/share/{token}", s.apiShare)
+	rt.Get("/metrics", s.apiMetrics)
+	rt.Post("/chat/command", s.apiChatCommand)
+	rt.Get("/live", s.apiLive)
+	rt.Get("/tv", s.apiTV)
+	rt.Get("/healthz", s.healthz)
+	rt.Get("/readyz", s.readyz)
+	rt.Get("/favicon.png", s.favicon)
+	rt.Get("/", s.apiHome)
 
-	fmt.Println("Listening :8080")
-	err := http.ListenAndServe("0.0.0.0:"+port, context.ClearHandler(http.DefaultServeMux))
-	return err
+	return rt
 }
 
 // initTemplates inits all templates in folder
@@ -93,6 +293,68 @@ func (s *Server) initTemplates(base string, fmap template.FuncMap) {
 	}
 }
 
+// expire forfeits challenges past their deadline and handles pending
+// matches nobody confirmed in time
+func (s *Server) expire(ctx context.Context, interval time.Duration) {
+	logger := logging.FromContext(ctx).With("job", "expire")
+	ctx = logging.WithLogger(ctx, logger)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		num, err := s.db.ExpireChallenges(ctx)
+		if err != nil {
+			logger.Error("expiring challenges failed", "err", err)
+		} else if num > 0 {
+			logger.Info("forfeited challenges", "count", num)
+		}
+
+		ids, err := s.db.ExpirePendingMatches(ctx, s.confirmTimeout, s.confirmOnTimeout)
+		if err != nil {
+			logger.Error("expiring pending matches failed", "err", err)
+			continue
+		}
+		if len(ids) > 0 {
+			logger.Info("expired pending matches", "count", len(ids))
+		}
+
+		// Confirmed on timeout count from now on
+		if s.confirmOnTimeout {
+			for _, id := range ids {
+				s.matchConfirmed(ctx, id)
+			}
+		}
+	}
+}
+
+// getenv returns environment variable or fallback if it's not set
+func getenv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// splitList splits comma separated list and drops empty entries
+func splitList(value string) []string {
+	list := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // getParams splits path and clears empty entries
 func getParams(path string) []string {
 	vars := strings.Split(path, "/")
@@ -117,3 +379,24 @@ func FormatDate(date string) string {
 	arr := strings.Split(date, "T")
 	return arr[0]
 }
+
+// FormatTime returns date and time without timezone
+func FormatTime(date string) string {
+	date = strings.Replace(date, "T", " ", 1)
+	return strings.TrimSuffix(date, "Z")
+}
+
+// FormatPoints shows half points without trailing zeros
+func FormatPoints(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// list makes slice of strings in templates
+func list(values ...string) []string {
+	return values
+}
+
+// inc is for 1-based numbering in templates
+func inc(i int) int {
+	return i + 1
+}
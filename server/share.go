@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/tuommii/jumbo/model"
+)
+
+const (
+	// Name is part of signature, share tokens don't work as other values
+	shareCookieName = "share"
+	// Share links expire after this many days if admin doesn't choose
+	defaultShareDays = 30
+	maxShareDays     = 365
+)
+
+// Share link can't be used
+var (
+	errShareInvalid = errors.New("Invalid link")
+	errShareExpired = errors.New("Link has expired")
+	errShareRevoked = errors.New("Link has been revoked")
+)
+
+// shareClaims is what share token carries, short keys keep links short.
+// Filter is read from the link, so it follows renamed players.
+type shareClaims struct {
+	ID      int   `json:"i"`
+	Expires int64 `json:"e"`
+}
+
+// newShareCodec signs share tokens with key
+func newShareCodec(key []byte) *securecookie.SecureCookie {
+	// Expiry is checked from claims, not from time token was made
+	return securecookie.New(key, nil).
+		MaxAge(0).
+		SetSerializer(securecookie.JSONEncoder{})
+}
+
+// shareToken makes signed token for share link
+func (s *Server) shareToken(l model.ShareLink) (string, error) {
+	expires, err := time.Parse(time.RFC3339, l.Expires)
+	if err != nil {
+		return "", err
+	}
+
+	return s.shareCodec.Encode(shareCookieName, shareClaims{
+		ID:      l.ID,
+		Expires: expires.Unix(),
+	})
+}
+
+// shareLink returns link of token if it's signed, not expired and not
+// revoked
+func (s *Server) shareLink(ctx context.Context, token string) (model.ShareLink, error) {
+	claims := shareClaims{}
+	err := s.shareCodec.Decode(shareCookieName, token, &claims)
+	if err != nil {
+		return model.ShareLink{}, errShareInvalid
+	}
+
+	if time.Now().Unix() >= claims.Expires {
+		return model.ShareLink{}, errShareExpired
+	}
+
+	link, err := s.db.GetShareLink(ctx, claims.ID)
+	if errors.Is(err, sql.ErrNoRows) || link.Revoked {
+		return model.ShareLink{}, errShareRevoked
+	}
+	return link, err
+}
+
+// apiShare shows read-only results of share link
+func (s *Server) apiShare(w http.ResponseWriter, r *http.Request) {
+	link, err := s.shareLink(r.Context(), r.PathValue("token"))
+	switch err {
+	case nil:
+	case errShareInvalid:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errShareExpired, errShareRevoked:
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	default:
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	f, err := link.Filter()
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Someone else's results shouldn't end up in search engines
+	w.Header().Set("X-Robots-Tag", "noindex")
+	s.renderResults(w, r, f, "", &link)
+}
+
+// adminShares lists share links and has form for new ones
+func (s *Server) adminShares(w http.ResponseWriter, r *http.Request) {
+	links, err := s.db.GetShareLinks(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	players, err := s.db.GetPlayers(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	games, err := s.db.GetGames(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type shareRow struct {
+		model.ShareLink
+		URL     string
+		Expired bool
+	}
+
+	base := s.baseURL(r)
+	now := time.Now()
+	rows := make([]shareRow, 0, len(links))
+	for _, l := range links {
+		token, err := s.shareToken(l)
+		if err != nil {
+			logError(r, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		expires, _ := time.Parse(time.RFC3339, l.Expires)
+		rows = append(rows, shareRow{l, base + "/share/" + token, !now.Before(expires)})
+	}
+
+	data := struct {
+		Links   []shareRow
+		Players []model.Player
+		Games   []model.Game
+		// Search results link here with their filter
+		Prefill model.Filter
+		Days    int
+	}{
+		rows,
+		players,
+		games,
+		filterFromRequest(r),
+		defaultShareDays,
+	}
+	s.templates["shares.html"].ExecuteTemplate(w, "base", data)
+}
+
+func (s *Server) adminCreateShare(w http.ResponseWriter, r *http.Request) {
+	days := defaultShareDays
+	if d := r.FormValue("days"); d != "" {
+		var err error
+		days, err = strconv.Atoi(d)
+		if err != nil || days < 1 || days > maxShareDays {
+			http.Error(w, "days must be 1-"+strconv.Itoa(maxShareDays), http.StatusBadRequest)
+			return
+		}
+	}
+
+	link := model.ShareLink{
+		Title:     strings.TrimSpace(r.FormValue("title")),
+		GameName:  r.FormValue("gameName"),
+		Player1:   r.FormValue("player1"),
+		Player2:   r.FormValue("player2"),
+		From:      r.FormValue("from"),
+		To:        r.FormValue("to"),
+		Expires:   time.Now().AddDate(0, 0, days).UTC().Format(time.RFC3339),
+		CreatedBy: s.actor(r),
+	}
+
+	if link.Player1 == "" && link.Player2 != "" {
+		link.Player1, link.Player2 = link.Player2, ""
+	}
+
+	f, err := link.Filter()
+	if err != nil {
+		http.Error(w, "dates must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	if link.Title == "" {
+		link.Title = feedTitle(f)
+	}
+
+	_, err = s.store(r).CreateShareLink(r.Context(), link)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/shares", http.StatusSeeOther)
+}
+
+func (s *Server) adminRevokeShare(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.store(r).RevokeShareLink(r.Context(), id)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/shares", http.StatusSeeOther)
+}
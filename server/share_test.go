@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/model"
+)
+
+// shareDB has share links by ID
+type shareDB struct {
+	database.Database
+	links map[int]model.ShareLink
+	err   error
+}
+
+func (db *shareDB) GetShareLink(ctx context.Context, id int) (model.ShareLink, error) {
+	if db.err != nil {
+		return model.ShareLink{}, db.err
+	}
+	l, ok := db.links[id]
+	if !ok {
+		return model.ShareLink{}, sql.ErrNoRows
+	}
+	return l, nil
+}
+
+func TestShareLink(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	links := map[int]model.ShareLink{
+		1: {ID: 1, GameName: "chess", Player1: "alice", Expires: future},
+		2: {ID: 2, Expires: past},
+		3: {ID: 3, Expires: future, Revoked: true},
+	}
+	dbErr := errors.New("database is locked")
+
+	s := &Server{db: &shareDB{links: links}, shareCodec: newShareCodec([]byte("s3cret"))}
+	other := &Server{shareCodec: newShareCodec([]byte("other"))}
+
+	token := func(s *Server, l model.ShareLink) string {
+		tok, err := s.shareToken(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	valid := token(s, links[1])
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+		dbErr error
+	}{
+		{"valid", valid, nil, nil},
+		{"tampered", valid[:len(valid)-4] + "AAA=", errShareInvalid, nil},
+		{"garbage", "not-a-token", errShareInvalid, nil},
+		{"empty", "", errShareInvalid, nil},
+		{"other key", token(other, links[1]), errShareInvalid, nil},
+		{"expired", token(s, links[2]), errShareExpired, nil},
+		{"revoked", token(s, links[3]), errShareRevoked, nil},
+		{"deleted", token(s, model.ShareLink{ID: 4, Expires: future}), errShareRevoked, nil},
+		{"database error", valid, dbErr, dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.db.(*shareDB).err = tt.dbErr
+
+			link, err := s.shareLink(context.Background(), tt.token)
+			if err != tt.err {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if err == nil && (link.ID != 1 || link.GameName != "chess" || link.Player1 != "alice") {
+				t.Errorf("got link %+v", link)
+			}
+		})
+	}
+}
+
+func TestShareLinkRevoke(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	db := &shareDB{links: map[int]model.ShareLink{1: {ID: 1, Expires: future}}}
+	s := &Server{db: db, shareCodec: newShareCodec([]byte("s3cret"))}
+
+	tok, err := s.shareToken(db.links[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.shareLink(context.Background(), tok)
+	if err != nil {
+		t.Fatalf("before revoke got error %v", err)
+	}
+
+	l := db.links[1]
+	l.Revoked = true
+	db.links[1] = l
+
+	_, err = s.shareLink(context.Background(), tok)
+	if err != errShareRevoked {
+		t.Errorf("after revoke got error %v, want %v", err, errShareRevoked)
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/sessions"
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/model"
+)
+
+const (
+	// Flash key for undoing latest delete, value is "kind:id"
+	undoFlashKey = "undo"
+	// Session value of latest delete, only it can be undone without admin
+	undoSessionKey = "undoable"
+)
+
+// flashUndo adds message with undo button to next page
+func (s *Server) flashUndo(w http.ResponseWriter, r *http.Request, kind string, id int, msg string) {
+	session, _ := s.cookies.Get(r, "mysession")
+	session.AddFlash(msg)
+	session.AddFlash(kind+":"+strconv.Itoa(id), undoFlashKey)
+	session.Values[undoSessionKey] = kind + ":" + strconv.Itoa(id)
+	session.Save(r, w)
+}
+
+// parseUndo returns nil for malformed flash
+func parseUndo(flash string) *model.Deleted {
+	parts := strings.SplitN(flash, ":", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil
+	}
+
+	return &model.Deleted{Kind: parts[0], ID: id}
+}
+
+// apiRestore is the undo button after delete, it restores only what was
+// deleted last in this session. Rest are restored from trash by admin.
+func (s *Server) apiRestore(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.cookies.Get(r, "mysession")
+
+	undoable, _ := session.Values[undoSessionKey].(string)
+	if undoable == "" || undoable != r.FormValue("kind")+":"+r.FormValue("id") {
+		http.Error(w, "only your latest delete can be undone, admin can restore others from trash", http.StatusForbidden)
+		return
+	}
+	delete(session.Values, undoSessionKey)
+
+	s.restore(w, r, session, "/")
+}
+
+// adminRestore restores anything in trash
+func (s *Server) adminRestore(w http.ResponseWriter, r *http.Request) {
+	session, _ := s.cookies.Get(r, "mysession")
+	s.restore(w, r, session, "/admin/trash")
+}
+
+func (s *Server) restore(w http.ResponseWriter, r *http.Request, session *sessions.Session, redirect string) {
+	kind := r.FormValue("kind")
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.store(r).Restore(r.Context(), kind, id)
+	if err == database.ErrUnknownKind {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	session.AddFlash("Restored " + kind + " " + strconv.Itoa(id))
+	session.Save(r, w)
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+func (s *Server) adminTrash(w http.ResponseWriter, r *http.Request) {
+	deleted, err := s.db.GetDeleted(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Deleted []model.Deleted
+	}{
+		deleted,
+	}
+	s.templates["trash.html"].ExecuteTemplate(w, "base", data)
+}
+
+func (s *Server) adminPurge(w http.ResponseWriter, r *http.Request) {
+	kind := r.FormValue("kind")
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.store(r).Purge(r.Context(), kind, id)
+	if err == database.ErrUnknownKind {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/trash", http.StatusSeeOther)
+}
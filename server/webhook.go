@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tuommii/jumbo/logging"
+	"github.com/tuommii/jumbo/model"
+	"github.com/tuommii/jumbo/webhook"
+)
+
+const (
+	// Due deliveries are looked for this often, new events wake sender
+	// right away
+	webhookInterval = 10 * time.Second
+	webhookBatch    = 50
+	webhookTimeout  = 10 * time.Second
+	// Deliveries shown in admin
+	deliveryLogLimit = 100
+)
+
+// leaderChange is data of leader.changed event
+type leaderChange struct {
+	GameName string `json:"gameName"`
+	Leader   string `json:"leader"`
+	Previous string `json:"previous"`
+}
+
+// matchChanged tells live streams and webhooks that match was added or
+// deleted, and webhooks if game got new leader. Pending matches are told
+// about when they are confirmed.
+func (s *Server) matchChanged(ctx context.Context, kind string, match model.Match) {
+	if match.Status != model.MatchConfirmed {
+		return
+	}
+
+	s.events.Publish(event{Kind: kind, Match: match})
+	s.notify(ctx, kind, match)
+
+	// Standings before change have deleted match, and don't have added one
+	var old *model.Match
+	if kind == model.EventMatchDeleted {
+		old = &match
+	}
+	s.checkLeader(ctx, match.GameName, match.ID, old)
+}
+
+// matchUpdated tells live streams and webhooks that match was edited, and
+// webhooks if leader of its old or new game changed
+func (s *Server) matchUpdated(ctx context.Context, old model.Match, match model.Match) {
+	if match.Status != model.MatchConfirmed {
+		return
+	}
+
+	s.events.Publish(event{Kind: model.EventMatchUpdated, Match: match})
+	s.notify(ctx, model.EventMatchUpdated, match)
+	s.checkLeader(ctx, match.GameName, match.ID, &old)
+
+	// Match moved to other game, board of old game changes too
+	if old.GameName != match.GameName {
+		s.events.Publish(event{Kind: model.EventMatchUpdated, Match: old})
+		s.checkLeader(ctx, old.GameName, match.ID, &old)
+	}
+}
+
+// checkLeader notifies webhooks if game's leader changed. Standings before
+// change had old version of match id, or no match id at all if old is nil.
+func (s *Server) checkLeader(ctx context.Context, gameName string, id int, old *model.Match) {
+	matches, err := s.db.GetMatches(ctx, model.Filter{GameName: gameName})
+	if err != nil {
+		logging.FromContext(ctx).Error("reading standings failed", "err", err)
+		return
+	}
+
+	before := make([]model.Match, 0, len(matches)+1)
+	for _, m := range matches {
+		if m.ID != id {
+			before = append(before, m)
+		}
+	}
+	if old != nil && old.GameName == gameName {
+		before = append(before, *old)
+	}
+
+	previous, _ := model.StatsFromMatches(before)
+	current, _ := model.StatsFromMatches(matches)
+	if previous.Leader() != current.Leader() {
+		s.notify(ctx, model.EventLeaderChanged, leaderChange{gameName, current.Leader(), previous.Leader()})
+	}
+}
+
+// notify queues event to webhooks. Change is already saved, so failure is
+// only logged.
+func (s *Server) notify(ctx context.Context, event string, data interface{}) {
+	payload, err := webhook.NewPayload(event, data)
+	if err != nil {
+		logging.FromContext(ctx).Error("creating webhook payload failed", "event", event, "err", err)
+		return
+	}
+
+	num, err := s.db.QueueEvent(ctx, event, string(payload))
+	if err != nil {
+		logging.FromContext(ctx).Error("queueing webhook event failed", "event", event, "err", err)
+		return
+	}
+	if num > 0 {
+		s.wakeWebhooks()
+	}
+}
+
+// wakeWebhooks makes sender look for due deliveries now
+func (s *Server) wakeWebhooks() {
+	select {
+	case s.webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverWebhooks sends due deliveries until ctx is cancelled
+func (s *Server) deliverWebhooks(ctx context.Context, interval time.Duration) {
+	logger := logging.FromContext(ctx).With("job", "webhook")
+	ctx = logging.WithLogger(ctx, logger)
+
+	client := &http.Client{Timeout: webhookTimeout}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.webhookWake:
+		}
+
+		deliveries, err := s.db.GetDueDeliveries(ctx, webhookBatch)
+		if err != nil {
+			logger.Error("reading deliveries failed", "err", err)
+			continue
+		}
+
+		for _, d := range deliveries {
+			s.deliver(ctx, logger, client, d)
+		}
+	}
+}
+
+// deliver makes one attempt, failed delivery is retried later until it has
+// used all attempts
+func (s *Server) deliver(ctx context.Context, logger *slog.Logger, client *http.Client, d model.Delivery) {
+	status, err := webhook.Send(ctx, client, d.URL, d.Secret, d.Event, d.ID, []byte(d.Payload))
+	if ctx.Err() != nil {
+		// Shutting down, attempt doesn't count
+		return
+	}
+
+	d.Attempts++
+	d.LastStatus = status
+	d.LastError = ""
+	d.Status = model.DeliveryDelivered
+	if err != nil {
+		d.LastError = err.Error()
+		d.Status = model.DeliveryPending
+		if d.Attempts >= webhook.MaxAttempts {
+			d.Status = model.DeliveryFailed
+		}
+	}
+
+	_, err = s.db.UpdateDelivery(ctx, d, webhook.Backoff(d.Attempts))
+	if err != nil {
+		logger.Error("saving delivery failed", "delivery", d.ID, "err", err)
+		return
+	}
+
+	logger.Info("webhook delivery", "delivery", d.ID, "event", d.Event, "status", d.Status,
+		"http_status", status, "attempts", d.Attempts, "err", d.LastError)
+}
+
+// adminWebhooks lists webhooks and delivery log
+func (s *Server) adminWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := s.db.GetWebhooks(r.Context())
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	deliveries, err := s.db.GetDeliveries(r.Context(), deliveryLogLimit)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Webhooks   []model.Webhook
+		Deliveries []model.Delivery
+		Events     []string
+	}{
+		webhooks,
+		deliveries,
+		model.Events,
+	}
+	s.templates["webhooks.html"].ExecuteTemplate(w, "base", data)
+}
+
+func (s *Server) adminCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	hook := model.Webhook{
+		URL:    strings.TrimSpace(r.FormValue("url")),
+		Secret: r.FormValue("secret"),
+		Events: r.Form["events"],
+	}
+
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "url must be http or https", http.StatusBadRequest)
+		return
+	}
+	if hook.Secret == "" {
+		http.Error(w, "secret required", http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.store(r).CreateWebhook(r.Context(), hook)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/webhooks", http.StatusSeeOther)
+}
+
+func (s *Server) adminDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.store(r).DeleteWebhook(r.Context(), id)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/webhooks", http.StatusSeeOther)
+}
+
+// adminPingWebhook sends ping event to webhook to test it
+func (s *Server) adminPingWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	payload, err := webhook.NewPayload(model.EventPing, map[string]int{"webhookId": id})
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = s.db.QueueDelivery(r.Context(), id, model.EventPing, string(payload))
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.wakeWebhooks()
+
+	http.Redirect(w, r, "/admin/webhooks", http.StatusSeeOther)
+}
+
+// adminRetryDelivery queues failed delivery again
+func (s *Server) adminRetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = s.db.RetryDelivery(r.Context(), id)
+	if err != nil {
+		logError(r, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.wakeWebhooks()
+
+	http.Redirect(w, r, "/admin/webhooks", http.StatusSeeOther)
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tuommii/jumbo/database"
+	"github.com/tuommii/jumbo/model"
+	"github.com/tuommii/jumbo/webhook"
+)
+
+// deliveryDB records deliveries saved by deliver
+type deliveryDB struct {
+	database.Database
+	saved   []model.Delivery
+	retryIn []time.Duration
+}
+
+func (db *deliveryDB) UpdateDelivery(ctx context.Context, d model.Delivery, retryIn time.Duration) (int64, error) {
+	db.saved = append(db.saved, d)
+	db.retryIn = append(db.retryIn, retryIn)
+	return 1, nil
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		want     []string
+	}{
+		{"first ok", []int{200}, []string{model.DeliveryDelivered}},
+		{"ok after retries", []int{500, 404, 204}, []string{model.DeliveryPending, model.DeliveryPending, model.DeliveryDelivered}},
+		{"gives up", []int{500, 500, 500, 500, 500, 500, 500, 500}, []string{
+			model.DeliveryPending, model.DeliveryPending, model.DeliveryPending, model.DeliveryPending,
+			model.DeliveryPending, model.DeliveryPending, model.DeliveryPending, model.DeliveryFailed,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer ts.Close()
+
+			db := &deliveryDB{}
+			s := &Server{db: db}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			d := model.Delivery{ID: 1, URL: ts.URL, Secret: "s3cret", Event: model.EventPing, Payload: "{}"}
+			for range tt.statuses {
+				s.deliver(context.Background(), logger, ts.Client(), d)
+				d = db.saved[len(db.saved)-1]
+			}
+
+			if len(db.saved) != len(tt.want) {
+				t.Fatalf("got %d saves, want %d", len(db.saved), len(tt.want))
+			}
+			for i, saved := range db.saved {
+				if saved.Status != tt.want[i] {
+					t.Errorf("attempt %d: got status %s, want %s", i+1, saved.Status, tt.want[i])
+				}
+				if saved.Attempts != i+1 {
+					t.Errorf("attempt %d: got %d attempts", i+1, saved.Attempts)
+				}
+				if saved.LastStatus != tt.statuses[i] {
+					t.Errorf("attempt %d: got last status %d, want %d", i+1, saved.LastStatus, tt.statuses[i])
+				}
+				if (saved.LastError == "") != (saved.Status == model.DeliveryDelivered) {
+					t.Errorf("attempt %d: got last error %q with status %s", i+1, saved.LastError, saved.Status)
+				}
+				if db.retryIn[i] != webhook.Backoff(i+1) {
+					t.Errorf("attempt %d: got retry in %s, want %s", i+1, db.retryIn[i], webhook.Backoff(i+1))
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverShutdown(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	db := &deliveryDB{}
+	s := &Server{db: db}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s.deliver(ctx, logger, ts.Client(), model.Delivery{ID: 1, URL: ts.URL, Event: model.EventPing})
+
+	if len(db.saved) != 0 {
+		t.Errorf("cancelled attempt was saved: %+v", db.saved)
+	}
+}
@@ -0,0 +1,67 @@
+// Keeps TV page up to date from /live stream, browser reconnects by itself
+(function() {
+    var live = document.getElementById('live');
+    var stats = document.getElementById('liveStats');
+    var matches = document.getElementById('liveMatches');
+    var status = document.getElementById('liveStatus');
+
+    function cell(row, text) {
+        var td = document.createElement('td');
+        td.textContent = text;
+        row.appendChild(td);
+    }
+
+    function span(parent, className, text) {
+        var s = document.createElement('span');
+        s.className = className;
+        s.textContent = text;
+        parent.appendChild(s);
+    }
+
+    function render(data) {
+        stats.innerHTML = '';
+        (data.stats || []).forEach(function(s, i) {
+            var row = document.createElement('tr');
+            cell(row, i + 1);
+            cell(row, s.name);
+            cell(row, s.games);
+            cell(row, s.wins);
+            cell(row, s.ties);
+            cell(row, s.losses);
+            cell(row, (s.winPercentage * 100).toFixed(0) + '%');
+            stats.appendChild(row);
+        });
+
+        matches.innerHTML = '';
+        (data.matches || []).forEach(function(m) {
+            var card = document.createElement('div');
+            card.className = 'match-card';
+            span(card, 'added', m.added.split('T')[0]);
+            span(card, 'player', m.winner + ' ');
+            span(card, 'vs', ' vs. ');
+            span(card, 'player', m.loser + ' ');
+            matches.appendChild(card);
+        });
+    }
+
+    var source = new EventSource('/live?gameName=' + encodeURIComponent(live.dataset.game));
+
+    source.addEventListener('standings', function(e) {
+        render(JSON.parse(e.data));
+    });
+
+    source.addEventListener('match', function(e) {
+        var ev = JSON.parse(e.data);
+        var verb = {'match.deleted': 'Removed: ', 'match.updated': 'Updated: '}[ev.kind] || 'New: ';
+        status.textContent = verb + ev.match.winner + ' vs. ' + ev.match.loser;
+    });
+
+    source.onopen = function() {
+        status.classList.remove('offline');
+    };
+
+    source.onerror = function() {
+        status.textContent = 'Reconnecting...';
+        status.classList.add('offline');
+    };
+})();
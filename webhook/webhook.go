@@ -0,0 +1,95 @@
+// Package webhook signs and sends event payloads, and verifies them on
+// receiving end
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Jumbo-Event"
+	HeaderDelivery  = "X-Jumbo-Delivery"
+	HeaderSignature = "X-Jumbo-Signature"
+)
+
+// Retries back off from first delay doubling up to max, delivery fails
+// after MaxAttempts
+const (
+	MaxAttempts = 8
+	firstDelay  = 30 * time.Second
+	maxDelay    = time.Hour
+)
+
+// Payload is JSON body of delivery
+type Payload struct {
+	Event   string      `json:"event"`
+	Created time.Time   `json:"created"`
+	Data    interface{} `json:"data"`
+}
+
+// NewPayload returns body for event with data
+func NewPayload(event string, data interface{}) ([]byte, error) {
+	return json.Marshal(Payload{event, time.Now().UTC(), data})
+}
+
+// Sign returns signature of body, "sha256=" and hex of HMAC-SHA256
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells if signature was made with secret for body
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Backoff returns delay before next attempt after attempts failed ones
+func Backoff(attempts int) time.Duration {
+	delay := firstDelay
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// Send posts signed body to url. Returns response status, which is 0 if
+// request failed, and error unless status was 2xx.
+func Send(ctx context.Context, client *http.Client, url string, secret string, event string, id int, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jumbo-webhook")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(id))
+	req.Header.Set(HeaderSignature, Sign(secret, body))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Drain so connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("webhook: %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
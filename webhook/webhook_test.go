@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		body      string
+		signature string
+	}{
+		// RFC 4231 test case 2
+		{"Jefe", "what do ya want for nothing?", "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"", "", "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			if got := Sign(tt.secret, []byte(tt.body)); got != tt.signature {
+				t.Errorf("got %s, want %s", got, tt.signature)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	signature := Sign("s3cret", body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		signature string
+		ok        bool
+	}{
+		{"valid", "s3cret", body, signature, true},
+		{"wrong secret", "other", body, signature, false},
+		{"changed body", "s3cret", []byte(`{"event":"pong"}`), signature, false},
+		{"missing prefix", "s3cret", body, signature[len("sha256="):], false},
+		{"truncated", "s3cret", body, signature[:len(signature)-1], false},
+		{"empty", "s3cret", body, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.body, tt.signature); got != tt.ok {
+				t.Errorf("got %v, want %v", got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		delay    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			if got := Backoff(tt.attempts); got != tt.delay {
+				t.Errorf("got %s, want %s", got, tt.delay)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		ok     bool
+	}{
+		{"ok", http.StatusOK, true},
+		{"no content", http.StatusNoContent, true},
+		{"redirect", http.StatusNotModified, false},
+		{"not found", http.StatusNotFound, false},
+		{"server error", http.StatusInternalServerError, false},
+	}
+
+	body := []byte(`{"event":"match.created"}`)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var gotBody []byte
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			status, err := Send(context.Background(), ts.Client(), ts.URL, "s3cret", "match.created", 42, body)
+			if status != tt.status {
+				t.Errorf("got status %d, want %d", status, tt.status)
+			}
+			if (err == nil) != tt.ok {
+				t.Errorf("got error %v, want ok %v", err, tt.ok)
+			}
+
+			if got.Method != "POST" {
+				t.Errorf("got method %s, want POST", got.Method)
+			}
+			if h := got.Header.Get(HeaderEvent); h != "match.created" {
+				t.Errorf("got event header %q", h)
+			}
+			if h := got.Header.Get(HeaderDelivery); h != "42" {
+				t.Errorf("got delivery header %q", h)
+			}
+			if !Verify("s3cret", gotBody, got.Header.Get(HeaderSignature)) {
+				t.Errorf("signature %q doesn't verify", got.Header.Get(HeaderSignature))
+			}
+		})
+	}
+}
+
+func TestSendUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	status, err := Send(context.Background(), ts.Client(), url, "s3cret", "ping", 1, []byte("{}"))
+	if status != 0 || err == nil {
+		t.Errorf("got status %d and error %v, want 0 and error", status, err)
+	}
+}